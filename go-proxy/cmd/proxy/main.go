@@ -1,61 +1,111 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
 	// Local imports
-	"go-proxy/internal/auth"
+	"go-proxy/internal/certreload"
 	"go-proxy/internal/config"
 	"go-proxy/internal/logger"
 	"go-proxy/internal/proxy"
 )
 
 func main() {
-	logger.Init()
+	listCiphers := flag.Bool("list-ciphers", false, "Print the compiled TLS cipher suites and exit")
+	flag.Parse()
+
+	if *listCiphers {
+		for _, suite := range tls.CipherSuites() {
+			fmt.Println(suite.Name)
+		}
+		for _, suite := range tls.InsecureCipherSuites() {
+			fmt.Println(suite.Name + " (insecure)")
+		}
+		return
+	}
+
+	logger.Init(logger.ConfigFromEnv())
 
 	cfg, err := config.New()
 	if err != nil {
-		logger.Log(logger.ERROR, "Configuration error", err)
+		logger.Log(logger.ERROR, "Configuration error", slog.Any("error", err))
 		os.Exit(1)
 	}
 	cfg.LogValues()
 
-	authManager := auth.NewManager(cfg)
-
-	var initialToken string
-	for i := 0; i < cfg.MaxRetries; i++ {
-		logger.Log(logger.INFO, "Attempting to authenticate", fmt.Sprintf("(Attempt %d/%d)", i+1, cfg.MaxRetries))
-		token, err := authManager.Authenticate()
-		if err == nil {
-			initialToken = token
-			break
-		}
-		logger.Log(logger.ERROR, "Authentication failed!", err)
-		if i < cfg.MaxRetries-1 {
-			logger.Log(logger.INFO, fmt.Sprintf("Retrying in %dms...", cfg.RetryInterval.Milliseconds()))
-			time.Sleep(cfg.RetryInterval)
-		}
-	}
-	if initialToken == "" {
-		logger.Log(logger.ERROR, "Could not authenticate after multiple retries. Exiting.")
+	// proxy.New builds the backend pool and authenticates every backend
+	// (retrying per backend per cfg.MaxRetries/RetryInterval) before
+	// returning. A backend that fails is marked unhealthy and left out of
+	// rotation; proxy.New returns an error only if every backend fails.
+	proxyHandler, err := proxy.New(cfg)
+	if err != nil {
+		logger.Log(logger.ERROR, "Failed to build proxy", slog.Any("error", err))
 		os.Exit(1)
 	}
-	logger.Log(logger.SUCCESS, "Authenticated and retrieved initial token.")
 
-	proxyHandler := proxy.New(cfg, authManager)
+	certStore, err := certreload.New("cert.pem", "key.pem")
+	if err != nil {
+		logger.Log(logger.ERROR, "Failed to load server certificate", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	listenAddress := ":" + cfg.ListenPort
 	server := &http.Server{
 		Addr:    listenAddress,
 		Handler: proxyHandler,
+		TLSConfig: &tls.Config{
+			GetCertificate: certStore.GetCertificate,
+		},
 	}
 
-	logger.Log(logger.SUCCESS, "Starting HTTPS reverse proxy", fmt.Sprintf("Listening on %s", cfg.ListenPort))
-	if err := server.ListenAndServeTLS("cert.pem", "key.pem"); err != nil {
-		logger.Log(logger.ERROR, "Failed to start server", err)
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logger.Log(logger.INFO, "Received SIGHUP, reloading server certificate")
+			if err := certStore.Reload(); err != nil {
+				logger.Log(logger.ERROR, "Failed to reload server certificate", slog.Any("error", err))
+			} else {
+				logger.Log(logger.SUCCESS, "Reloaded server certificate")
+			}
+		}
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Log(logger.SUCCESS, "Starting HTTPS reverse proxy", slog.String("listen_port", cfg.ListenPort))
+		// Cert/key paths are already loaded into server.TLSConfig via
+		// certStore, so ListenAndServeTLS is told to use that config as-is.
+		serveErr <- server.ListenAndServeTLS("", "")
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log(logger.ERROR, "Failed to start server", slog.Any("error", err))
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+		logger.Log(logger.INFO, "Shutdown signal received, draining connections", slog.Duration("grace_period", cfg.ShutdownGrace))
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Log(logger.ERROR, "Graceful shutdown timed out, forcing close", slog.Any("error", err))
+			server.Close()
+		}
+		proxy.Wait()
+		logger.Log(logger.SUCCESS, "Shutdown complete")
 	}
 }
@@ -2,19 +2,19 @@ package auth
 
 import (
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"sync"
 	"time"
 
 	// Local imports
 	"go-proxy/internal/config"
 	"go-proxy/internal/logger"
+	"go-proxy/internal/resilience"
 )
 
 type authRequest struct {
@@ -26,34 +26,157 @@ type authResponse struct {
 	MasterToken string `json:"master_token"`
 }
 
+// authStatusError is returned by performAuthentication when the auth
+// endpoint responds with a non-OK status, so callers can tell transient
+// upstream failures (5xx) apart from rejected credentials (4xx).
+type authStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *authStatusError) Error() string {
+	return fmt.Sprintf("auth endpoint returned %d: %s", e.StatusCode, e.Body)
+}
+
+// shouldRetryAuth reports whether an error from performAuthentication is
+// worth retrying: network-level errors and 5xx responses are transient,
+// while a 4xx means the credentials themselves were rejected.
+func shouldRetryAuth(err error) bool {
+	var statusErr *authStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	return true
+}
+
 type Manager struct {
-	config            *config.Config
-	token             string
-	lastReauthAttempt time.Time
-	tokenMutex        sync.RWMutex
-	reauthMutex       sync.Mutex
+	config *config.Config
+
+	// client is the shared http.Client auth requests go through, built once
+	// from config.Transport so every auth call reuses the same connection
+	// pool instead of dialing fresh each time.
+	client *http.Client
+
+	// currentToken is what GetToken hands out. A refresh computes the new
+	// token without holding tokenMutex (since it takes a network round trip) and
+	// swaps it in under a single short tokenMutex.Lock, so GetToken's
+	// RLock is never held for anywhere near the duration of a refresh.
+	currentToken string
+	tokenMutex   sync.RWMutex
+
+	lastReauthAttempt   time.Time
+	consecutiveFailures int
+	reauthMutex         sync.Mutex
+
+	refreshTimer *time.Timer
+	refreshMu    sync.Mutex
+
+	breaker *resilience.Breaker
+	retry   resilience.RetryConfig
 }
 
-func NewManager(cfg *config.Config) *Manager {
+func NewManager(cfg *config.Config) (*Manager, error) {
+	tlsConfig, err := cfg.TLS.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build TLS config: %w", err)
+	}
+	transport, err := cfg.Transport.Build(tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build upstream transport: %w", err)
+	}
+
 	return &Manager{
 		config: cfg,
-	}
+		client: &http.Client{Transport: transport},
+		breaker: resilience.NewBreaker(resilience.BreakerConfig{
+			FailureRatio: cfg.Resilience.BreakerFailureRatio,
+			MinRequests:  cfg.Resilience.BreakerMinRequests,
+			OpenDuration: cfg.Resilience.BreakerOpenDuration,
+		}),
+		retry: resilience.RetryConfig{
+			MaxAttempts: cfg.Resilience.RetryMaxAttempts,
+			BaseDelay:   cfg.Resilience.RetryBaseDelay,
+			MaxDelay:    cfg.Resilience.RetryMaxDelay,
+		},
+	}, nil
 }
 
+// Authenticate fetches an initial token and schedules its proactive
+// refresh. Callers that want the refresher running should use this
+// instead of authenticateWithResilience directly.
 func (m *Manager) Authenticate() (string, error) {
-	token, err := m.performAuthentication()
+	token, err := m.authenticateWithResilience()
 	if err != nil {
 		return "", err
 	}
+	m.promoteToken(token)
+	m.scheduleProactiveRefresh(token)
+	return token, nil
+}
+
+// promoteToken swaps token into currentToken, holding tokenMutex only for
+// the swap itself.
+func (m *Manager) promoteToken(token string) {
 	m.tokenMutex.Lock()
-	m.token = token
+	m.currentToken = token
 	m.tokenMutex.Unlock()
+}
+
+// authenticateWithResilience wraps performAuthentication with the shared
+// circuit breaker and retryer: repeated upstream failures trip the breaker
+// and fail fast instead of hammering a down auth endpoint, while transient
+// 5xx/network errors are retried with full-jitter backoff.
+func (m *Manager) authenticateWithResilience() (string, error) {
+	if !m.breaker.Allow() {
+		state, _, _ := m.breaker.State()
+		return "", fmt.Errorf("auth circuit breaker is %s: failing fast", state)
+	}
+
+	var token string
+	err := resilience.Retry(m.retry, shouldRetryAuth, func() error {
+		t, err := m.performAuthentication()
+		if err != nil {
+			return err
+		}
+		token = t
+		return nil
+	})
+
+	if err != nil {
+		m.breaker.RecordFailure()
+		return "", err
+	}
+	m.breaker.RecordSuccess()
 	return token, nil
 }
 
+// BreakerMetrics exposes the auth circuit breaker's state and counts for
+// logging or a /metrics-style endpoint.
+func (m *Manager) BreakerMetrics() resilience.Metrics {
+	return m.breaker.Metrics()
+}
+
+// Ready reports whether this backend should be considered ready to serve:
+// it has a token from a successful authentication, and its last
+// config.ReauthFailureThreshold re-auth attempts haven't all failed. A
+// /readyz endpoint built on this lets a load balancer drain an instance
+// whose credentials have stopped working.
+func (m *Manager) Ready() bool {
+	m.tokenMutex.RLock()
+	hasToken := m.currentToken != ""
+	m.tokenMutex.RUnlock()
+	if !hasToken {
+		return false
+	}
+
+	m.reauthMutex.Lock()
+	defer m.reauthMutex.Unlock()
+	return m.consecutiveFailures < m.config.ReauthFailureThreshold
+}
+
 func (m *Manager) performAuthentication() (string, error) {
 	authURL := "https://" + m.config.TargetHost + ":" + m.config.TargetPort + "/api/v1/auth"
-	logger.Log(logger.INFO, "Posting to auth endpoint", "URL: "+authURL)
+	logger.Log(logger.INFO, "Posting to auth endpoint", slog.String("url", authURL))
 
 	requestBody := authRequest{
 		ClientID:     m.config.ClientID,
@@ -65,18 +188,16 @@ func (m *Manager) performAuthentication() (string, error) {
 		return "", fmt.Errorf("failed to marshal auth request: %w", err)
 	}
 
-	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
-	client := &http.Client{Transport: transport}
-	response, err := client.Post(authURL, "application/json", bytes.NewBuffer(requestBytes))
+	response, err := m.client.Post(authURL, "application/json", bytes.NewBuffer(requestBytes))
 	if err != nil {
 		return "", err
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		logger.Log(logger.WARN, "Authentication failed with non-OK status.", fmt.Sprintf("Status: %d %s", response.StatusCode, http.StatusText(response.StatusCode)))
+		logger.Log(logger.WARN, "Authentication failed with non-OK status.", slog.Int("status", response.StatusCode), slog.String("status_text", http.StatusText(response.StatusCode)))
 		bodyBytes, _ := io.ReadAll(response.Body)
-		return "", &url.Error{Op: response.Request.Method, URL: authURL, Err: &net.AddrError{Err: "auth failed", Addr: string(bodyBytes)}}
+		return "", &authStatusError{StatusCode: response.StatusCode, Body: string(bodyBytes)}
 	}
 
 	var respData authResponse
@@ -89,28 +210,82 @@ func (m *Manager) performAuthentication() (string, error) {
 func (m *Manager) GetToken() string {
 	m.tokenMutex.RLock()
 	defer m.tokenMutex.RUnlock()
-	return m.token
+	return m.currentToken
 }
 
+// RefreshTokenIfNeeded is the reactive fallback for when the proactive
+// refresher wasn't fast enough: call it whenever the target rejects a
+// token with 401/403. Consecutive failed refreshes push the retry backoff
+// out exponentially from config.Cooldown (the initial delay), so a target
+// that's rejecting every credential doesn't get hammered with refreshes.
 func (m *Manager) RefreshTokenIfNeeded() {
 	m.reauthMutex.Lock()
 	defer m.reauthMutex.Unlock()
 
-	if time.Since(m.lastReauthAttempt) < m.config.Cooldown {
-		logger.Log(logger.INFO, "Re-authentication cooldown active. Please wait.")
+	backoff := m.config.Cooldown << minInt(m.consecutiveFailures, 6)
+	if time.Since(m.lastReauthAttempt) < backoff {
+		logger.Log(logger.INFO, "Re-authentication backoff active. Please wait.", slog.Duration("backoff", backoff))
 		return
 	}
 	m.lastReauthAttempt = time.Now()
 
-	logger.Log(logger.INFO, "Attempting to refresh token in background.")
-	newToken, err := m.performAuthentication()
+	logger.Log(logger.INFO, "Attempting forced token refresh in background.", slog.Int("consecutive_failures", m.consecutiveFailures))
+	token, err := m.authenticateWithResilience()
 	if err != nil {
-		logger.Log(logger.ERROR, "Failed to refresh token", err)
+		m.consecutiveFailures++
+		logger.Log(logger.ERROR, "Failed to refresh token", slog.Any("error", err), slog.Int("consecutive_failures", m.consecutiveFailures))
 		return
 	}
 
-	m.tokenMutex.Lock()
-	m.token = newToken
-	m.tokenMutex.Unlock()
+	m.consecutiveFailures = 0
+	m.promoteToken(token)
+	m.scheduleProactiveRefresh(token)
 	logger.Log(logger.SUCCESS, "Successfully refreshed master token.")
 }
+
+// scheduleProactiveRefresh arranges for token to be refreshed before it
+// expires: at exp-TokenRefreshSkew for a JWT (parsed, unverified, from the
+// exp claim), or every TokenTTL for an opaque token. It replaces any
+// refresh already scheduled from a previous token.
+func (m *Manager) scheduleProactiveRefresh(token string) {
+	delay := m.config.TokenTTL
+	if exp, ok := jwtExpiry(token); ok {
+		delay = time.Until(exp) - m.config.TokenRefreshSkew
+	}
+	if delay <= 0 {
+		delay = m.config.TokenRefreshSkew
+	}
+
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	if m.refreshTimer != nil {
+		m.refreshTimer.Stop()
+	}
+	m.refreshTimer = time.AfterFunc(delay, m.proactiveRefresh)
+}
+
+// proactiveRefresh is the scheduled-refresh callback: on success it
+// promotes the new token and reschedules itself from the new expiry; on
+// failure it retries after config.RetryInterval rather than leaving the
+// token to expire unrefreshed.
+func (m *Manager) proactiveRefresh() {
+	logger.Log(logger.INFO, "Proactively refreshing token before expiry.")
+	token, err := m.authenticateWithResilience()
+	if err != nil {
+		logger.Log(logger.ERROR, "Proactive token refresh failed; retrying", slog.Any("error", err), slog.Duration("retry_in", m.config.RetryInterval))
+		m.refreshMu.Lock()
+		m.refreshTimer = time.AfterFunc(m.config.RetryInterval, m.proactiveRefresh)
+		m.refreshMu.Unlock()
+		return
+	}
+	m.promoteToken(token)
+	logger.Log(logger.SUCCESS, "Proactively refreshed token.")
+	m.scheduleProactiveRefresh(token)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
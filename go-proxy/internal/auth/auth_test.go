@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	// Local imports
+	"go-proxy/internal/config"
+)
+
+// newTestConfig builds a *config.Config pointed at server, with TLS
+// verification skipped (as a fresh checkout would be, with no CA bundle
+// configured) so it can talk to httptest.NewTLSServer's self-signed cert.
+func newTestConfig(t *testing.T, server *httptest.Server) *config.Config {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatalf("failed to split test server host:port: %v", err)
+	}
+
+	return &config.Config{
+		TargetHost:       host,
+		TargetPort:       port,
+		ClientID:         "test-client",
+		ClientSecret:     "test-secret",
+		Cooldown:         time.Minute,
+		MaxRetries:       1,
+		RetryInterval:    time.Millisecond,
+		TokenRefreshSkew: time.Second,
+		TokenTTL:         time.Hour,
+		Resilience: config.ResilienceConfig{
+			BreakerFailureRatio: 0.5,
+			BreakerMinRequests:  1000,
+			BreakerOpenDuration: time.Minute,
+			RetryMaxAttempts:    1,
+			RetryBaseDelay:      time.Millisecond,
+			RetryMaxDelay:       time.Millisecond,
+		},
+	}
+}
+
+func TestManager_Authenticate_ReturnsTokenFromServer(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(authResponse{MasterToken: "tok-1"})
+	}))
+	defer server.Close()
+
+	mgr, err := NewManager(newTestConfig(t, server))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	token, err := mgr.Authenticate()
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if token != "tok-1" {
+		t.Errorf("Authenticate returned %q, want %q", token, "tok-1")
+	}
+	if got := mgr.GetToken(); got != "tok-1" {
+		t.Errorf("GetToken() = %q, want %q", got, "tok-1")
+	}
+}
+
+func TestManager_Authenticate_NonOKStatusIsNotRetried(t *testing.T) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(t, server)
+	cfg.MaxRetries = 3
+	cfg.Resilience.RetryMaxAttempts = 3
+	mgr, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	if _, err := mgr.Authenticate(); err == nil {
+		t.Fatal("Authenticate: want error for 403 response, got nil")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("auth endpoint called %d times, want 1 (4xx shouldn't be retried)", got)
+	}
+}
+
+func TestManager_RefreshTokenIfNeeded_CooldownSuppressesConcurrentCalls(t *testing.T) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		json.NewEncoder(w).Encode(authResponse{MasterToken: fmt.Sprintf("tok-%d", n)})
+	}))
+	defer server.Close()
+
+	mgr, err := NewManager(newTestConfig(t, server))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	const concurrency = 10
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			mgr.RefreshTokenIfNeeded()
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("auth endpoint called %d times for %d concurrent refreshes within the cooldown window, want 1", got, concurrency)
+	}
+	if got := mgr.GetToken(); got != "tok-1" {
+		t.Errorf("GetToken() = %q, want %q", got, "tok-1")
+	}
+}
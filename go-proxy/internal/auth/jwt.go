@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// jwtClaims is the subset of registered JWT claims this package cares
+// about: the expiry used to schedule a proactive refresh.
+type jwtClaims struct {
+	Exp float64 `json:"exp"`
+}
+
+// jwtExpiry reports the exp claim of token, treated as a JWT (unverified:
+// the proxy never trusts the token's contents, only its shape). ok is
+// false if token isn't a three-part JWT, its payload segment isn't valid
+// base64url JSON, or it carries no exp claim — callers should fall back to
+// a fixed-interval refresh for such opaque tokens.
+func jwtExpiry(token string) (exp time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(claims.Exp), 0), true
+}
@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	// Local imports
+	"go-proxy/internal/logger"
+)
+
+// AuthenticateAll authenticates every backend's auth.Manager before the
+// proxy starts serving traffic, retrying each one up to maxRetries times
+// with retryInterval between attempts. A backend that exhausts its retries
+// is marked unhealthy instead of aborting startup, so the health checker
+// and load balancer can route around it and retry it later; AuthenticateAll
+// only returns an error once every backend has failed, since a pool with
+// zero working backends has nothing to route to.
+func AuthenticateAll(backends []*Backend, maxRetries int, retryInterval time.Duration) error {
+	failures := 0
+	for _, b := range backends {
+		if err := authenticateWithRetry(b, maxRetries, retryInterval); err != nil {
+			logger.Log(logger.ERROR, "Backend failed initial authentication, marking unhealthy", slog.String("backend", b.Key()), slog.Any("error", err))
+			b.SetHealthy(false)
+			failures++
+		}
+	}
+	if failures == len(backends) {
+		return fmt.Errorf("no backend authenticated successfully (%d/%d failed)", failures, len(backends))
+	}
+	return nil
+}
+
+func authenticateWithRetry(b *Backend, maxRetries int, retryInterval time.Duration) error {
+	var lastErr error
+	for i := 0; i < maxRetries; i++ {
+		logger.Log(logger.INFO, "Attempting to authenticate backend",
+			slog.String("backend", b.Key()), slog.Int("attempt", i+1), slog.Int("max_attempts", maxRetries))
+
+		if _, err := b.Auth.Authenticate(); err != nil {
+			lastErr = err
+			logger.Log(logger.ERROR, "Backend authentication failed", slog.String("backend", b.Key()), slog.Any("error", err))
+			if i < maxRetries-1 {
+				logger.Log(logger.INFO, "Retrying backend authentication", slog.String("backend", b.Key()), slog.Duration("retry_in", retryInterval))
+				time.Sleep(retryInterval)
+			}
+			continue
+		}
+		logger.Log(logger.SUCCESS, "Authenticated backend and retrieved initial token.", slog.String("backend", b.Key()))
+		return nil
+	}
+	return fmt.Errorf("could not authenticate after %d retries: %w", maxRetries, lastErr)
+}
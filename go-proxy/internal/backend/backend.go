@@ -0,0 +1,116 @@
+// Package backend models a pool of target servers the proxy load-balances
+// across: per-backend health, in-flight request counts, auth token, and
+// circuit breaker, plus the Pool selection strategies and background
+// health checker built on top of them.
+package backend
+
+import (
+	"fmt"
+	"sync"
+
+	// Local imports
+	"go-proxy/internal/auth"
+	"go-proxy/internal/config"
+	"go-proxy/internal/resilience"
+)
+
+// Backend is one target server in the pool.
+type Backend struct {
+	Name   string
+	Host   string
+	Port   string
+	Weight int
+
+	// Breaker is this backend's own circuit breaker: a failing backend
+	// trips independently, so it sheds traffic without taking down the
+	// whole pool.
+	Breaker *resilience.Breaker
+
+	// Auth is this backend's own auth.Manager: it authenticates and
+	// re-authenticates against this backend specifically, so a token
+	// rejection or re-auth cooldown on one backend doesn't affect the
+	// others.
+	Auth *auth.Manager
+
+	mu      sync.RWMutex
+	healthy bool
+	active  int
+}
+
+// NewBackend builds a Backend with its own circuit breaker and auth
+// manager, derived from cfg but targeting host/port instead of
+// cfg.TargetHost/TargetPort. Weight <= 0 is normalized to 1. Backends
+// start healthy; the health checker (if running) will correct that on its
+// first probe.
+func NewBackend(name, host, port string, weight int, cfg *config.Config) (*Backend, error) {
+	if weight <= 0 {
+		weight = 1
+	}
+	backendCfg := *cfg
+	backendCfg.TargetHost = host
+	backendCfg.TargetPort = port
+
+	breakerCfg := resilience.BreakerConfig{
+		FailureRatio: cfg.Resilience.BreakerFailureRatio,
+		MinRequests:  cfg.Resilience.BreakerMinRequests,
+		OpenDuration: cfg.Resilience.BreakerOpenDuration,
+	}
+
+	authManager, err := auth.NewManager(&backendCfg)
+	if err != nil {
+		return nil, fmt.Errorf("backend %q: %w", name, err)
+	}
+
+	return &Backend{
+		Name:    name,
+		Host:    host,
+		Port:    port,
+		Weight:  weight,
+		Breaker: resilience.NewBreaker(breakerCfg),
+		Auth:    authManager,
+		healthy: true,
+	}, nil
+}
+
+// Key identifies this backend for logs, metrics, and as its req.URL.Host.
+func (b *Backend) Key() string {
+	return b.Host + ":" + b.Port
+}
+
+func (b *Backend) Healthy() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.healthy
+}
+
+func (b *Backend) SetHealthy(healthy bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.healthy = healthy
+}
+
+// Active returns the number of in-flight requests currently routed to this
+// backend, used by the least-connections strategy.
+func (b *Backend) Active() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.active
+}
+
+func (b *Backend) IncActive() {
+	b.mu.Lock()
+	b.active++
+	b.mu.Unlock()
+}
+
+func (b *Backend) DecActive() {
+	b.mu.Lock()
+	b.active--
+	b.mu.Unlock()
+}
+
+// Available reports whether the backend may currently be selected: marked
+// healthy by the health checker and not presently circuit-broken.
+func (b *Backend) Available() bool {
+	return b.Healthy() && b.Breaker.CanAttempt()
+}
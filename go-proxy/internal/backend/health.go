@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	// Local imports
+	"go-proxy/internal/logger"
+)
+
+// DefaultHealthPath and DefaultHealthInterval are used whenever a pool
+// config doesn't specify its own.
+const (
+	DefaultHealthPath     = "/health"
+	DefaultHealthInterval = 10 * time.Second
+)
+
+// StartHealthChecks launches a goroutine that probes healthPath on every
+// backend at the given interval, flipping Backend.SetHealthy based on
+// whether the probe returned 200 OK. It runs until stop is closed. transport
+// is the same shared upstream transport used for proxied traffic, so health
+// probes honor the same outbound proxy/TLS settings.
+func StartHealthChecks(backends []*Backend, healthPath string, interval time.Duration, transport http.RoundTripper, stop <-chan struct{}) {
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   5 * time.Second,
+	}
+
+	probe := func(b *Backend) {
+		url := fmt.Sprintf("https://%s%s", b.Key(), healthPath)
+		resp, err := client.Get(url)
+		healthy := err == nil && resp.StatusCode == http.StatusOK
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if healthy != b.Healthy() {
+			logger.Log(logger.INFO, "Backend health changed", slog.String("backend", b.Key()), slog.Bool("healthy", healthy))
+		}
+		b.SetHealthy(healthy)
+	}
+
+	checkAll := func() {
+		for _, b := range backends {
+			probe(b)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		checkAll()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				checkAll()
+			}
+		}
+	}()
+}
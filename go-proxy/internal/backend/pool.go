@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrNoAvailableBackends is returned by Select when every backend in the
+// pool is either unhealthy or circuit-broken.
+var ErrNoAvailableBackends = errors.New("no available backends")
+
+// Strategy selects how Pool.Select picks among available backends.
+type Strategy string
+
+const (
+	WeightedRoundRobin Strategy = "weighted_round_robin"
+	LeastConnections   Strategy = "least_connections"
+	ConsistentHash     Strategy = "consistent_hash"
+)
+
+// hashRingReplicas is the number of virtual nodes per backend on the
+// consistent-hash ring; more replicas spread load more evenly at the cost
+// of a bigger ring to search.
+const hashRingReplicas = 100
+
+// Pool is a set of backends load-balanced according to Strategy.
+type Pool struct {
+	Strategy   Strategy
+	HashHeader string // header consulted by ConsistentHash; empty means client IP
+
+	mu       sync.Mutex
+	backends []*Backend
+	rrCursor int
+}
+
+// NewPool builds a Pool over backends using strategy. An unrecognized or
+// empty strategy falls back to WeightedRoundRobin.
+func NewPool(backends []*Backend, strategy Strategy, hashHeader string) *Pool {
+	return &Pool{backends: backends, Strategy: strategy, HashHeader: hashHeader}
+}
+
+// Backends returns every backend in the pool, available or not.
+func (p *Pool) Backends() []*Backend {
+	return p.backends
+}
+
+// Ready reports whether the pool can currently serve traffic: at least one
+// backend is healthy and its auth.Manager is Ready. A pool with some
+// backends stuck re-authenticating but at least one healthy, Ready one
+// stays ready. Select does not consult auth readiness: it skips only
+// unhealthy or circuit-broken backends.
+func (p *Pool) Ready() bool {
+	for _, b := range p.backends {
+		if b.Healthy() && b.Auth.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Select picks a backend for req according to Strategy, considering only
+// Available (healthy, not circuit-broken) backends.
+func (p *Pool) Select(req *http.Request) (*Backend, error) {
+	available := p.availableBackends()
+	if len(available) == 0 {
+		return nil, ErrNoAvailableBackends
+	}
+
+	switch p.Strategy {
+	case LeastConnections:
+		return p.selectLeastConnections(available), nil
+	case ConsistentHash:
+		return p.selectConsistentHash(available, req), nil
+	default:
+		return p.selectWeightedRoundRobin(available), nil
+	}
+}
+
+func (p *Pool) availableBackends() []*Backend {
+	available := make([]*Backend, 0, len(p.backends))
+	for _, b := range p.backends {
+		if b.Available() {
+			available = append(available, b)
+		}
+	}
+	return available
+}
+
+func (p *Pool) selectWeightedRoundRobin(available []*Backend) *Backend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	total := 0
+	for _, b := range available {
+		total += b.Weight
+	}
+	if total == 0 {
+		return available[0]
+	}
+
+	p.rrCursor = (p.rrCursor + 1) % total
+	cursor := p.rrCursor
+	for _, b := range available {
+		if cursor < b.Weight {
+			return b
+		}
+		cursor -= b.Weight
+	}
+	return available[len(available)-1]
+}
+
+func (p *Pool) selectLeastConnections(available []*Backend) *Backend {
+	best := available[0]
+	for _, b := range available[1:] {
+		if b.Active() < best.Active() {
+			best = b
+		}
+	}
+	return best
+}
+
+// selectConsistentHash hashes req's key onto a ring built from the
+// available backends (hashRingReplicas virtual nodes each), so membership
+// changes remap only the keys that land near the affected backend instead
+// of reshuffling the whole pool.
+func (p *Pool) selectConsistentHash(available []*Backend, req *http.Request) *Backend {
+	type ringEntry struct {
+		hash    uint64
+		backend *Backend
+	}
+
+	ring := make([]ringEntry, 0, len(available)*hashRingReplicas)
+	for _, b := range available {
+		for i := 0; i < hashRingReplicas; i++ {
+			ring = append(ring, ringEntry{hash: hash64(fmt.Sprintf("%s#%d", b.Key(), i)), backend: b})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	keyHash := hash64(p.hashKey(req))
+	idx := sort.Search(len(ring), func(i int) bool { return ring[i].hash >= keyHash })
+	if idx == len(ring) {
+		idx = 0
+	}
+	return ring[idx].backend
+}
+
+func (p *Pool) hashKey(req *http.Request) string {
+	if p.HashHeader != "" {
+		if v := req.Header.Get(p.HashHeader); v != "" {
+			return v
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+func hash64(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
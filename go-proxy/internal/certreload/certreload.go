@@ -0,0 +1,51 @@
+// Package certreload lets the server's TLS certificate be rotated on disk
+// and picked up without a process restart.
+package certreload
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+)
+
+// Store holds the server keypair loaded from certPath/keyPath and serves it
+// through GetCertificate, so a *tls.Config built from it can have its
+// certificate swapped out from under live listeners via Reload.
+type Store struct {
+	certPath string
+	keyPath  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// New loads certPath/keyPath and returns a Store wrapping them.
+func New(certPath, keyPath string) (*Store, error) {
+	s := &Store{certPath: certPath, keyPath: keyPath}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads certPath/keyPath from disk and atomically swaps them in.
+// Connections already established keep the certificate they handshook
+// with; only handshakes after Reload returns see the new one.
+func (s *Store) Reload() error {
+	cert, err := tls.LoadX509KeyPair(s.certPath, s.keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS keypair %q/%q: %w", s.certPath, s.keyPath, err)
+	}
+	s.mu.Lock()
+	s.cert = &cert
+	s.mu.Unlock()
+	return nil
+}
+
+// GetCertificate is a tls.Config.GetCertificate callback returning the
+// currently loaded certificate, regardless of the requested SNI.
+func (s *Store) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert, nil
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	// Local imports
@@ -11,14 +15,52 @@ import (
 )
 
 type Config struct {
-	ListenPort    string
-	TargetHost    string
-	TargetPort    string
-	ClientID      string
-	ClientSecret  string
-	Cooldown      time.Duration
-	MaxRetries    int
-	RetryInterval time.Duration
+	ListenPort       string
+	TargetHost       string
+	TargetPort       string
+	ClientID         string
+	ClientSecret     string
+	Cooldown         time.Duration
+	MaxRetries       int
+	RetryInterval    time.Duration
+	TokenRefreshSkew time.Duration
+	TokenTTL         time.Duration
+	TLS              TLSConfig
+	FiltersConfig    string
+	Resilience       ResilienceConfig
+	BackendPool      string
+	Transport        TransportConfig
+	AdminAddr        string
+	ShutdownGrace    time.Duration
+
+	// ReauthFailureThreshold is how many consecutive failed re-auth
+	// attempts a backend can accumulate before /readyz reports it unready.
+	ReauthFailureThreshold int
+}
+
+// ResilienceConfig tunes the circuit breaker and retryer wrapping upstream
+// calls (auth requests and proxied traffic). Every field has a default, so
+// a fresh checkout keeps working without any extra configuration.
+type ResilienceConfig struct {
+	BreakerFailureRatio float64
+	BreakerMinRequests  int
+	BreakerOpenDuration time.Duration
+	RetryMaxAttempts    int
+	RetryBaseDelay      time.Duration
+	RetryMaxDelay       time.Duration
+}
+
+// TLSConfig describes how the proxy authenticates the target server (and,
+// optionally, itself) over TLS. All fields are optional: with CABundlePath
+// unset the proxy falls back to InsecureSkipVerify, logging a warning, so a
+// fresh checkout still runs without certificates on hand.
+type TLSConfig struct {
+	CABundlePath   string // verifies the target's certificate
+	ClientCertPath string // presented to the target for mTLS
+	ClientKeyPath  string
+	ServerName     string // overrides SNI / certificate hostname verification
+	MinVersion     string // "1.2" or "1.3", default "1.2"
+	CipherSuites   []string
 }
 
 func New() (*Config, error) {
@@ -69,13 +111,160 @@ func New() (*Config, error) {
 	}
 	cfg.RetryInterval = time.Duration(intervalMs) * time.Millisecond
 
+	cfg.TokenRefreshSkew = getEnvDurationMs("GO_PROXY_TOKEN_REFRESH_SKEW_MS", 30000)
+	cfg.TokenTTL = getEnvDurationMs("GO_PROXY_TOKEN_TTL_MS", 300000)
+
+	cfg.TLS = TLSConfig{
+		CABundlePath:   os.Getenv("GO_PROXY_TLS_CA_BUNDLE"),
+		ClientCertPath: os.Getenv("GO_PROXY_TLS_CLIENT_CERT"),
+		ClientKeyPath:  os.Getenv("GO_PROXY_TLS_CLIENT_KEY"),
+		ServerName:     os.Getenv("GO_PROXY_TLS_SERVER_NAME"),
+		MinVersion:     os.Getenv("GO_PROXY_TLS_MIN_VERSION"),
+	}
+	if suites := os.Getenv("GO_PROXY_TLS_CIPHER_SUITES"); suites != "" {
+		cfg.TLS.CipherSuites = strings.Split(suites, ",")
+	}
+
+	cfg.FiltersConfig = os.Getenv("GO_PROXY_FILTERS_CONFIG")
+	cfg.BackendPool = os.Getenv("GO_PROXY_BACKEND_POOL_CONFIG")
+	cfg.AdminAddr = os.Getenv("GO_PROXY_ADMIN_ADDR")
+	cfg.ShutdownGrace = getEnvDurationMs("GO_PROXY_SHUTDOWN_GRACE_MS", 10000)
+	cfg.ReauthFailureThreshold = getEnvInt("GO_PROXY_REAUTH_FAILURE_THRESHOLD", 3)
+
+	cfg.Resilience = ResilienceConfig{
+		BreakerFailureRatio: getEnvFloat("GO_PROXY_BREAKER_FAILURE_RATIO", 0.5),
+		BreakerMinRequests:  getEnvInt("GO_PROXY_BREAKER_MIN_REQUESTS", 5),
+		BreakerOpenDuration: getEnvDurationMs("GO_PROXY_BREAKER_OPEN_MS", 30000),
+		RetryMaxAttempts:    getEnvInt("GO_PROXY_UPSTREAM_RETRY_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:      getEnvDurationMs("GO_PROXY_UPSTREAM_RETRY_BASE_MS", 100),
+		RetryMaxDelay:       getEnvDurationMs("GO_PROXY_UPSTREAM_RETRY_MAX_MS", 2000),
+	}
+
+	cfg.Transport = TransportConfig{
+		ProxyURL:              os.Getenv("GO_PROXY_UPSTREAM_PROXY_URL"),
+		DialTimeout:           getEnvDurationMs("GO_PROXY_UPSTREAM_DIAL_TIMEOUT_MS", 10000),
+		IdleConnTimeout:       getEnvDurationMs("GO_PROXY_UPSTREAM_IDLE_CONN_TIMEOUT_MS", 90000),
+		ExpectContinueTimeout: getEnvDurationMs("GO_PROXY_UPSTREAM_EXPECT_CONTINUE_TIMEOUT_MS", 1000),
+		ForceHTTP2:            getEnvBool("GO_PROXY_UPSTREAM_FORCE_HTTP2", true),
+	}
+
 	return cfg, nil
 }
 
+// Build turns t into a *tls.Config suitable for an outbound http.Transport.
+// With no CA bundle configured it intentionally skips verification rather
+// than failing closed, so deployments can adopt mTLS incrementally.
+func (t TLSConfig) Build() (*tls.Config, error) {
+	if t.CABundlePath == "" {
+		logger.Log(logger.WARN, "GO_PROXY_TLS_CA_BUNDLE not set; skipping target certificate verification")
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	caBytes, err := os.ReadFile(t.CABundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS CA bundle %q: %w", t.CABundlePath, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in TLS CA bundle %q", t.CABundlePath)
+	}
+
+	tlsCfg := &tls.Config{
+		RootCAs:    pool,
+		ServerName: t.ServerName,
+		MinVersion: parseTLSVersion(t.MinVersion),
+	}
+
+	if t.ClientCertPath != "" || t.ClientKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(t.ClientCertPath, t.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client keypair: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(t.CipherSuites) > 0 {
+		suites, err := parseCipherSuites(t.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.CipherSuites = suites
+	}
+
+	return tlsCfg, nil
+}
+
+func parseTLSVersion(v string) uint16 {
+	switch v {
+	case "1.3":
+		return tls.VersionTLS13
+	default:
+		return tls.VersionTLS12
+	}
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	available := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		available[s.Name] = s.ID
+	}
+
+	suites := make([]uint16, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		id, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}
+
 func (c *Config) GetCredentials() (clientID, clientSecret string) {
 	return c.ClientID, c.ClientSecret
 }
 
+func getEnvInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func getEnvFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+func getEnvDurationMs(key string, defMs int) time.Duration {
+	return time.Duration(getEnvInt(key, defMs)) * time.Millisecond
+}
+
+func getEnvBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
 func getRequiredEnv(key string) (string, error) {
 	value, ok := os.LookupEnv(key)
 	if !ok || value == "" {
@@ -85,13 +274,29 @@ func getRequiredEnv(key string) (string, error) {
 }
 
 func (c *Config) LogValues() {
-	logger.Log(logger.INFO, "Configuration loaded successfully")
-	logger.Log(logger.INFO, " - Listen Port: "+c.ListenPort)
-	logger.Log(logger.INFO, " - Target Host: "+c.TargetHost)
-	logger.Log(logger.INFO, " - Target Port: "+c.TargetPort)
-	logger.Log(logger.INFO, " - Client ID: "+c.ClientID)
-	logger.Log(logger.INFO, " - Client Secret: [REDACTED]")
-	logger.Log(logger.INFO, " - Max Retries: "+strconv.Itoa(c.MaxRetries))
-	logger.Log(logger.INFO, " - Retry Interval: "+c.RetryInterval.String())
-	logger.Log(logger.INFO, " - Re-auth Cooldown: "+c.Cooldown.String())
+	logger.Log(logger.INFO, "Configuration loaded successfully",
+		slog.String("listen_port", c.ListenPort),
+		slog.String("target_host", c.TargetHost),
+		slog.String("target_port", c.TargetPort),
+		slog.String("client_id", c.ClientID),
+		slog.String("client_secret", "[REDACTED]"),
+		slog.Int("max_retries", c.MaxRetries),
+		slog.Duration("retry_interval", c.RetryInterval),
+		slog.Duration("reauth_cooldown", c.Cooldown),
+		slog.Duration("token_refresh_skew", c.TokenRefreshSkew),
+		slog.Duration("token_ttl", c.TokenTTL),
+		slog.String("tls_ca_bundle", c.TLS.CABundlePath),
+		slog.String("tls_server_name", c.TLS.ServerName),
+		slog.String("filters_config", c.FiltersConfig),
+		slog.String("backend_pool_config", c.BackendPool),
+		slog.String("admin_addr", c.AdminAddr),
+		slog.Duration("shutdown_grace", c.ShutdownGrace),
+		slog.Int("reauth_failure_threshold", c.ReauthFailureThreshold),
+		slog.Float64("breaker_failure_ratio", c.Resilience.BreakerFailureRatio),
+		slog.Int("breaker_min_requests", c.Resilience.BreakerMinRequests),
+		slog.Duration("breaker_open_duration", c.Resilience.BreakerOpenDuration),
+		slog.String("upstream_proxy_url", c.Transport.ProxyURL),
+		slog.Duration("upstream_dial_timeout", c.Transport.DialTimeout),
+		slog.Duration("upstream_idle_conn_timeout", c.Transport.IdleConnTimeout),
+		slog.Bool("upstream_force_http2", c.Transport.ForceHTTP2))
 }
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig tunes the single *http.Transport shared by every outbound
+// HTTP call the proxy makes — auth requests and proxied traffic alike — so
+// both get the same connection pooling, dial/idle timeouts, and outbound
+// proxy handling instead of each call site growing its own.
+type TransportConfig struct {
+	// ProxyURL overrides the default http.ProxyFromEnvironment behavior
+	// (HTTP_PROXY/HTTPS_PROXY/NO_PROXY). Empty means honor the environment.
+	ProxyURL              string
+	DialTimeout           time.Duration
+	IdleConnTimeout       time.Duration
+	ExpectContinueTimeout time.Duration
+	// ForceHTTP2 sets http.Transport.ForceAttemptHTTP2: the standard library
+	// already negotiates HTTP/2 over TLS given ALPN support, so this is the
+	// only outbound HTTP/2 knob needed without a third-party dependency.
+	ForceHTTP2 bool
+}
+
+// Build returns the shared upstream *http.Transport, wired with tlsConfig
+// and t's proxy/timeout/HTTP2 settings.
+func (t TransportConfig) Build(tlsConfig *tls.Config) (*http.Transport, error) {
+	proxyFunc := http.ProxyFromEnvironment
+	if t.ProxyURL != "" {
+		proxyURL, err := url.Parse(t.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GO_PROXY_UPSTREAM_PROXY_URL %q: %w", t.ProxyURL, err)
+		}
+		proxyFunc = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{Timeout: t.DialTimeout}
+
+	return &http.Transport{
+		Proxy:                 proxyFunc,
+		TLSClientConfig:       tlsConfig,
+		DialContext:           dialer.DialContext,
+		IdleConnTimeout:       t.IdleConnTimeout,
+		ExpectContinueTimeout: t.ExpectContinueTimeout,
+		ForceAttemptHTTP2:     t.ForceHTTP2,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ruleConfig is the on-disk JSON shape of a single Rule.
+type ruleConfig struct {
+	MatchPrefix string         `json:"match_prefix"`
+	MatchExact  string         `json:"match_exact"`
+	Filters     []filterConfig `json:"filters"`
+}
+
+// filterConfig is the on-disk JSON shape of a single Filter. Type selects
+// which filter is built; the remaining fields are interpreted according to
+// Type, and fields unused by a given type are ignored.
+type filterConfig struct {
+	Type string `json:"type"`
+
+	// html_inject
+	Position string `json:"position"`
+	Tag      string `json:"tag"`
+	HTML     string `json:"html"`
+
+	// header
+	Add     map[string]string `json:"add"`
+	Remove  []string          `json:"remove"`
+	Rewrite map[string]string `json:"rewrite"`
+
+	// regex_sub
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	ByteLimit   int    `json:"byte_limit"`
+
+	// cookie_domain
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// LoadPipeline reads a JSON rules file (see ruleConfig/filterConfig for the
+// schema) and builds the Pipeline the proxy's ModifyResponse will run. Only
+// JSON is supported; a .yaml/.yml path is rejected up front with a clear
+// error instead of failing deep inside json.Unmarshal.
+func LoadPipeline(path string) (*Pipeline, error) {
+	if isYAMLPath(path) {
+		return nil, fmt.Errorf("filter config %q: YAML is not supported, write this as JSON", path)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter config %q: %w", path, err)
+	}
+
+	var rawRules []ruleConfig
+	if err := json.Unmarshal(raw, &rawRules); err != nil {
+		return nil, fmt.Errorf("failed to parse filter config %q: %w", path, err)
+	}
+
+	rules := make([]Rule, 0, len(rawRules))
+	for _, rc := range rawRules {
+		var match PathPredicate
+		switch {
+		case rc.MatchExact != "":
+			match = ExactPath(rc.MatchExact)
+		case rc.MatchPrefix != "":
+			match = PrefixPath(rc.MatchPrefix)
+		default:
+			return nil, fmt.Errorf("rule in %q has neither match_exact nor match_prefix", path)
+		}
+
+		filters := make([]Filter, 0, len(rc.Filters))
+		for _, fc := range rc.Filters {
+			filter, err := buildFilter(fc)
+			if err != nil {
+				return nil, fmt.Errorf("in %q: %w", path, err)
+			}
+			filters = append(filters, filter)
+		}
+
+		rules = append(rules, Rule{Match: match, Filters: filters})
+	}
+
+	return NewPipeline(rules...), nil
+}
+
+func buildFilter(fc filterConfig) (Filter, error) {
+	switch fc.Type {
+	case "html_inject":
+		return HTMLInjectFilter{Position: InjectPosition(fc.Position), Tag: fc.Tag, HTML: fc.HTML}, nil
+	case "header":
+		return HeaderFilter{Add: fc.Add, Remove: fc.Remove, Rewrite: fc.Rewrite}, nil
+	case "regex_sub":
+		pattern, err := regexp.Compile(fc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex_sub pattern %q: %w", fc.Pattern, err)
+		}
+		return RegexSubFilter{Pattern: pattern, Replacement: fc.Replacement, ByteLimit: fc.ByteLimit}, nil
+	case "cookie_domain":
+		return CookieDomainFilter{From: fc.From, To: fc.To}, nil
+	default:
+		return nil, fmt.Errorf("unknown filter type %q", fc.Type)
+	}
+}
+
+// isYAMLPath reports whether path's extension marks it as YAML.
+func isYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// InjectPosition controls where HTMLInjectFilter places its HTML relative to
+// the matched tag.
+type InjectPosition string
+
+const (
+	InjectBefore  InjectPosition = "before"
+	InjectAfter   InjectPosition = "after"
+	InjectReplace InjectPosition = "replace"
+)
+
+// HTMLInjectFilter inserts HTML immediately before, after, or in place of
+// the first occurrence of Tag in the response body.
+type HTMLInjectFilter struct {
+	Position InjectPosition
+	Tag      string
+	HTML     string
+}
+
+func (f HTMLInjectFilter) Apply(res *http.Response) error {
+	if res.StatusCode != http.StatusOK {
+		return nil
+	}
+	return transformBody(res, func(dst io.Writer, src io.Reader) error {
+		return injectStreaming(dst, src, f.Tag, f.HTML, f.Position)
+	})
+}
+
+// injectStreaming copies src to dst, injecting html relative to the first
+// occurrence of tag. It only ever holds len(tag)-1 bytes of carry-over
+// between reads, so it never buffers the whole body looking for the tag.
+func injectStreaming(dst io.Writer, src io.Reader, tag, html string, pos InjectPosition) error {
+	if tag == "" {
+		_, err := io.Copy(dst, src)
+		return err
+	}
+
+	const chunkSize = 32 * 1024
+	buf := make([]byte, chunkSize)
+	carry := make([]byte, 0, len(tag))
+	injected := false
+
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			window := append(carry, buf[:n]...)
+
+			if !injected {
+				if idx := bytes.Index(window, []byte(tag)); idx >= 0 {
+					if _, err := dst.Write(window[:idx]); err != nil {
+						return err
+					}
+					switch pos {
+					case InjectBefore:
+						io.WriteString(dst, html)
+						io.WriteString(dst, tag)
+					case InjectReplace:
+						io.WriteString(dst, html)
+					default: // InjectAfter
+						io.WriteString(dst, tag)
+						io.WriteString(dst, html)
+					}
+					injected = true
+					window = window[idx+len(tag):]
+				}
+			}
+
+			keep := len(tag) - 1
+			if len(window) > keep {
+				flushLen := len(window) - keep
+				if _, err := dst.Write(window[:flushLen]); err != nil {
+					return err
+				}
+				carry = append(carry[:0], window[flushLen:]...)
+			} else {
+				carry = append(carry[:0], window...)
+			}
+		}
+		if readErr == io.EOF {
+			_, err := dst.Write(carry)
+			return err
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// HeaderFilter removes, adds, and rewrites (sets) response headers, in that
+// order.
+type HeaderFilter struct {
+	Add     map[string]string
+	Remove  []string
+	Rewrite map[string]string
+}
+
+func (f HeaderFilter) Apply(res *http.Response) error {
+	for _, name := range f.Remove {
+		res.Header.Del(name)
+	}
+	for name, value := range f.Add {
+		res.Header.Add(name, value)
+	}
+	for name, value := range f.Rewrite {
+		res.Header.Set(name, value)
+	}
+	return nil
+}
+
+// defaultRegexScanLimit bounds how much of the body RegexSubFilter buffers
+// to run its regex over when ByteLimit is left unset: transformBody exists
+// so gigabyte responses don't get fully buffered, and a regex scan with no
+// cap at all would silently reintroduce that same OOM risk.
+const defaultRegexScanLimit = 1 << 20 // 1 MiB
+
+// RegexSubFilter replaces all matches of Pattern with Replacement, within
+// the first ByteLimit bytes of the body. ByteLimit <= 0 falls back to
+// defaultRegexScanLimit rather than scanning the whole body unbounded.
+// Bytes beyond the limit are passed through untouched.
+type RegexSubFilter struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+	ByteLimit   int
+}
+
+func (f RegexSubFilter) Apply(res *http.Response) error {
+	return transformBody(res, func(dst io.Writer, src io.Reader) error {
+		limit := f.ByteLimit
+		if limit <= 0 {
+			limit = defaultRegexScanLimit
+		}
+
+		head, err := io.ReadAll(io.LimitReader(src, int64(limit)))
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Write(f.Pattern.ReplaceAll(head, []byte(f.Replacement))); err != nil {
+			return err
+		}
+
+		_, err = io.Copy(dst, src)
+		return err
+	})
+}
+
+// CookieDomainFilter rewrites the Domain attribute of Set-Cookie headers,
+// for reverse-proxy deployments where the target's cookie domain doesn't
+// match the domain clients see.
+type CookieDomainFilter struct {
+	From string
+	To   string
+}
+
+func (f CookieDomainFilter) Apply(res *http.Response) error {
+	cookies := res.Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	res.Header.Del("Set-Cookie")
+	for _, cookie := range cookies {
+		if cookie.Domain == f.From {
+			cookie.Domain = f.To
+		}
+		res.Header.Add("Set-Cookie", cookie.String())
+	}
+	return nil
+}
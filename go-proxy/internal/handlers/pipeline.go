@@ -0,0 +1,81 @@
+// Package handlers implements the proxy's response-transform pipeline: an
+// ordered list of Rules, each pairing a path predicate with the Filters to
+// run against any response whose request path matches.
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PathPredicate decides whether a Rule's Filters apply to a given request path.
+type PathPredicate func(path string) bool
+
+// ExactPath matches a single literal path, e.g. "/wheredidicomefrom".
+func ExactPath(path string) PathPredicate {
+	return func(p string) bool { return p == path }
+}
+
+// PrefixPath matches any path beginning with prefix.
+func PrefixPath(prefix string) PathPredicate {
+	return func(p string) bool { return strings.HasPrefix(p, prefix) }
+}
+
+// Filter mutates a proxied response before it reaches the client.
+type Filter interface {
+	Apply(res *http.Response) error
+}
+
+// Rule pairs a path predicate with the filters to run when it matches.
+type Rule struct {
+	Match   PathPredicate
+	Filters []Filter
+}
+
+// Pipeline is the ordered list of Rules proxy.ModifyResponse consults for
+// every response it receives from the target.
+type Pipeline struct {
+	Rules []Rule
+}
+
+// NewPipeline builds a Pipeline from the given rules, evaluated in order.
+func NewPipeline(rules ...Rule) *Pipeline {
+	return &Pipeline{Rules: rules}
+}
+
+// Apply runs the Filters of every Rule whose Match matches res's request
+// path, in rule order. A nil Pipeline is a no-op, so callers may run it
+// unconditionally even when no config was loaded.
+func (p *Pipeline) Apply(res *http.Response) error {
+	if p == nil {
+		return nil
+	}
+	path := res.Request.URL.Path
+	for _, rule := range p.Rules {
+		if !rule.Match(path) {
+			continue
+		}
+		for _, filter := range rule.Filters {
+			if err := filter.Apply(res); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultPipeline reproduces the proxy's original hard-coded
+// "/wheredidicomefrom" HTML-injection demo, used whenever
+// GO_PROXY_FILTERS_CONFIG isn't set.
+func DefaultPipeline() *Pipeline {
+	return NewPipeline(Rule{
+		Match: ExactPath("/wheredidicomefrom"),
+		Filters: []Filter{
+			HTMLInjectFilter{
+				Position: InjectBefore,
+				Tag:      "</body>",
+				HTML:     `<p style="color: green; font-weight: bold;">Injected by the Go Proxy!</p>`,
+			},
+		},
+	})
+}
@@ -1,47 +1,222 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-type Level int
+// Level is a slog.Level, kept as its own name so call sites don't need to
+// import log/slog directly.
+type Level = slog.Level
 
 const (
-	INFO Level = iota
-	WARN
-	ERROR
-	SUCCESS
+	DEBUG   = slog.LevelDebug
+	INFO    = slog.LevelInfo
+	SUCCESS = slog.Level(2) // between INFO and WARN: a notable, non-error milestone
+	WARN    = slog.LevelWarn
+	ERROR   = slog.LevelError
 )
 
-func (l Level) String() string {
-	switch l {
-	case INFO:
-		return "INFO"
-	case WARN:
-		return "WARN"
-	case ERROR:
-		return "ERROR"
-	case SUCCESS:
-		return "SUCCESS"
+// Config controls how Init builds the process-wide logger.
+type Config struct {
+	Format     string // "text" (default) or "json"
+	Level      string // "debug", "info", "warn", "error" (default "info")
+	FilePath   string // optional rotating file sink; stdout is used when empty
+	MaxSizeMB  int    // size at which the file sink rotates (default 100)
+	MaxBackups int    // rotated files to keep (default 3, 0 = unlimited)
+	MaxAgeDays int    // max age of a rotated file before pruning (default 28, 0 = unlimited)
+}
+
+// ConfigFromEnv builds a Config from LOG_FORMAT, LOG_LEVEL, LOG_FILE,
+// LOG_MAX_SIZE_MB, LOG_MAX_BACKUPS and LOG_MAX_AGE_DAYS, applying the same
+// defaults Config documents for anything unset or invalid.
+func ConfigFromEnv() Config {
+	return Config{
+		Format:     os.Getenv("LOG_FORMAT"),
+		Level:      os.Getenv("LOG_LEVEL"),
+		FilePath:   os.Getenv("LOG_FILE"),
+		MaxSizeMB:  envInt("LOG_MAX_SIZE_MB", 100),
+		MaxBackups: envInt("LOG_MAX_BACKUPS", 3),
+		MaxAgeDays: envInt("LOG_MAX_AGE_DAYS", 28),
+	}
+}
+
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+var std *slog.Logger
+
+// Init configures the process-wide logger from cfg. It is safe to call more
+// than once (e.g. to pick up a changed level); the latest call wins.
+func Init(cfg Config) {
+	var out io.Writer = os.Stdout
+	if cfg.FilePath != "" {
+		out = newRotatingWriter(cfg.FilePath, cfg.MaxSizeMB, cfg.MaxBackups, cfg.MaxAgeDays)
+	}
+
+	opts := &slog.HandlerOptions{Level: parseLevel(cfg.Level), ReplaceAttr: replaceLevelName}
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "json") {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
+	std = slog.New(handler)
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return DEBUG
+	case "warn", "warning":
+		return WARN
+	case "error":
+		return ERROR
 	default:
-		return "UNKNOWN"
+		return INFO
 	}
 }
 
-func Init() {
-	log.SetFlags(0)
+func replaceLevelName(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey {
+		if lvl, ok := a.Value.Any().(slog.Level); ok && lvl == SUCCESS {
+			a.Value = slog.StringValue("SUCCESS")
+		}
+	}
+	return a
 }
 
-func Log(level Level, message string, extras ...interface{}) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-	levelStr := fmt.Sprintf("%-7s", level)
-	logMessage := fmt.Sprintf("[%s] %s :: %s", timestamp, levelStr, message)
+// Log emits msg at level with the given slog-style key/value args, e.g.
+// logger.Log(logger.INFO, "forwarded request", slog.String("client_ip", ip), slog.Int("status", code))
+func Log(level Level, msg string, args ...any) {
+	if std == nil {
+		Init(Config{})
+	}
+	std.Log(context.Background(), level, msg, args...)
+}
 
-	if len(extras) > 0 {
-		logMessage += " :: " + fmt.Sprint(extras...)
+// rotatingWriter is a minimal in-tree implementation of a size-based
+// rotating file sink: once the active file would exceed maxSize it is
+// renamed aside with a timestamp suffix, and backups older than maxAge or
+// beyond maxBackups are pruned.
+type rotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+}
+
+func newRotatingWriter(path string, maxSizeMB, maxBackups, maxAgeDays int) io.Writer {
+	w := &rotatingWriter{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+	}
+	if err := w.open(); err != nil {
+		fmt.Fprintf(os.Stderr, "logger: failed to open log file %q, falling back to stdout: %v\n", path, err)
+		return os.Stdout
 	}
+	return w
+}
 
-	log.Println(logMessage)
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+	}
+
+	backupPath := fmt.Sprintf("%s.%s", w.path, time.Now().Format("20060102T150405.000"))
+	if err := os.Rename(w.path, backupPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.pruneBackups()
+	return nil
+}
+
+func (w *rotatingWriter) pruneBackups() {
+	dir := filepath.Dir(w.path)
+	base := filepath.Base(w.path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var backups []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), base+".") {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(backups) // the timestamp suffix sorts lexicographically == chronologically
+
+	now := time.Now()
+	kept := backups[:0]
+	for _, b := range backups {
+		if w.maxAge > 0 {
+			if info, err := os.Stat(b); err == nil && now.Sub(info.ModTime()) > w.maxAge {
+				os.Remove(b)
+				continue
+			}
+		}
+		kept = append(kept, b)
+	}
+
+	if w.maxBackups > 0 && len(kept) > w.maxBackups {
+		for _, b := range kept[:len(kept)-w.maxBackups] {
+			os.Remove(b)
+		}
+	}
 }
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the proxy_request_duration_seconds histogram's upper
+// bounds, matching Prometheus's own client library defaults.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// globalMetrics is the process-wide Prometheus-style registry backing
+// /metrics: there's exactly one proxy per process, so a package-level
+// registry (mirroring the package-level logger) avoids threading it through
+// every call site that needs to record something.
+var globalMetrics = newMetricsRegistry()
+
+type requestCounterKey struct {
+	status string
+	method string
+}
+
+// metricsRegistry accumulates proxy_requests_total, proxy_request_duration_seconds,
+// proxy_reauth_total, and proxy_upstream_errors_total.
+type metricsRegistry struct {
+	mu sync.Mutex
+
+	requestsTotal map[requestCounterKey]int64
+
+	durationCount int64
+	durationSum   float64
+	bucketCounts  []int64 // parallel to durationBuckets, cumulative per-bucket counts
+
+	reauthTotal         int64
+	upstreamErrorsTotal int64
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{
+		requestsTotal: make(map[requestCounterKey]int64),
+		bucketCounts:  make([]int64, len(durationBuckets)),
+	}
+}
+
+// observeRequest records one completed request's status, method, and
+// upstream latency.
+func (m *metricsRegistry) observeRequest(status, method string, dur time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requestsTotal[requestCounterKey{status: status, method: method}]++
+
+	seconds := dur.Seconds()
+	m.durationCount++
+	m.durationSum += seconds
+	for i, le := range durationBuckets {
+		if seconds <= le {
+			m.bucketCounts[i]++
+		}
+	}
+}
+
+func (m *metricsRegistry) incReauth() {
+	m.mu.Lock()
+	m.reauthTotal++
+	m.mu.Unlock()
+}
+
+func (m *metricsRegistry) incUpstreamError() {
+	m.mu.Lock()
+	m.upstreamErrorsTotal++
+	m.mu.Unlock()
+}
+
+// writeMetrics renders the registry in Prometheus text exposition format.
+// Named to avoid accidentally satisfying io.WriterTo, whose WriteTo must
+// return (int64, error).
+func (m *metricsRegistry) writeMetrics(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]requestCounterKey, 0, len(m.requestsTotal))
+	for k := range m.requestsTotal {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].status != keys[j].status {
+			return keys[i].status < keys[j].status
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	fmt.Fprintln(w, "# TYPE proxy_requests_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "proxy_requests_total{status=%q,method=%q} %d\n", k.status, k.method, m.requestsTotal[k])
+	}
+
+	fmt.Fprintln(w, "# TYPE proxy_request_duration_seconds histogram")
+	for i, le := range durationBuckets {
+		fmt.Fprintf(w, "proxy_request_duration_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(le, 'g', -1, 64), m.bucketCounts[i])
+	}
+	fmt.Fprintf(w, "proxy_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.durationCount)
+	fmt.Fprintf(w, "proxy_request_duration_seconds_sum %g\n", m.durationSum)
+	fmt.Fprintf(w, "proxy_request_duration_seconds_count %d\n", m.durationCount)
+
+	fmt.Fprintln(w, "# TYPE proxy_reauth_total counter")
+	fmt.Fprintf(w, "proxy_reauth_total %d\n", m.reauthTotal)
+
+	fmt.Fprintln(w, "# TYPE proxy_upstream_errors_total counter")
+	fmt.Fprintf(w, "proxy_upstream_errors_total %d\n", m.upstreamErrorsTotal)
+}
@@ -1,71 +1,498 @@
 package proxy
 
 import (
-	"crypto/tls"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
 	"net"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
+	"strconv"
+	"sync"
+	"time"
 
 	// Local imports
-	"go-proxy/internal/auth"
+	"go-proxy/internal/backend"
 	"go-proxy/internal/config"
 	"go-proxy/internal/handlers"
 	"go-proxy/internal/logger"
+	"go-proxy/internal/resilience"
 )
 
-func New(cfg *config.Config, authManager *auth.Manager) *httputil.ReverseProxy {
-	targetURL, _ := url.Parse("https://" + cfg.TargetHost + ":" + cfg.TargetPort)
-	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+type startTimeKey struct{}
+
+// backendKey is the context key resilientTransport.RoundTrip stashes the
+// selected *backend.Backend under, so ModifyResponse can scope
+// re-authentication to the backend that actually served the request.
+type backendKey struct{}
+
+// requestIDKey is the context key withRequestID stashes this request's
+// correlation ID under, so every log line for a request (across Director
+// and ModifyResponse) can be tied back together.
+type requestIDKey struct{}
+
+// withRequestID wraps next with a per-request correlation ID: reused from
+// an incoming X-Request-Id if the client already set one, otherwise freshly
+// generated. It's stashed on the request's context for logging and echoed
+// back as the X-Request-Id response header before next runs, so it's present
+// even if next errors out.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get("X-Request-Id")
+		if id == "" {
+			id = newRequestID()
+		}
+		rw.Header().Set("X-Request-Id", id)
+		next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id)))
+	})
+}
+
+// sseFlushWriter wraps an http.ResponseWriter so that, once a response is
+// recognized as Server-Sent Events, every subsequent Write is flushed to
+// the client immediately instead of waiting on httputil.ReverseProxy's
+// default periodic-flush batching. Recognition happens as early as
+// possible: at construction from the request path (sseRoutes), and again
+// at WriteHeader from the response Content-Type, since a stream's path
+// isn't always on the allow-list.
+type sseFlushWriter struct {
+	http.ResponseWriter
+	flusher http.Flusher
+	sse     bool
+}
+
+func (w *sseFlushWriter) WriteHeader(status int) {
+	if !w.sse {
+		w.sse = isSSEContentType(w.Header())
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sseFlushWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	if w.sse && w.flusher != nil {
+		w.flusher.Flush()
+	}
+	return n, err
+}
+
+// streamSSE wraps next so that responses isSSEResponse would recognize as
+// Server-Sent Events are flushed to the client on every write, without
+// disabling ReverseProxy's normal periodic-flush batching for ordinary
+// traffic.
+func streamSSE(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		flusher, ok := rw.(http.Flusher)
+		if !ok {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		w := &sseFlushWriter{ResponseWriter: rw, flusher: flusher, sse: isSSERoute(req.URL.Path)}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// newRequestID returns a random 128-bit hex-encoded correlation ID.
+func newRequestID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("id-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// requestID returns the correlation ID stashed in ctx by withRequestID, or
+// "" if none was (e.g. a request that bypassed it).
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// backgroundReauth tracks the reactive re-auth goroutines ModifyResponse
+// spawns off 401/403 responses, so Wait can block shutdown until they've
+// all finished instead of leaving one to race a process exit.
+var backgroundReauth sync.WaitGroup
+
+// Wait blocks until every in-flight background re-authentication started by
+// ModifyResponse has finished. Callers should run it after server.Shutdown
+// returns, before the process exits.
+func Wait() {
+	backgroundReauth.Wait()
+}
+
+// sseRoutes is the allow-list of request paths known to serve Server-Sent
+// Events, consulted before the response (and its Content-Type) exists yet.
+var sseRoutes = []handlers.PathPredicate{
+	handlers.ExactPath("/stream"),
+}
+
+func isSSERoute(path string) bool {
+	for _, match := range sseRoutes {
+		if match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSSEContentType reports whether h's Content-Type is text/event-stream.
+func isSSEContentType(h http.Header) bool {
+	contentType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return contentType == "text/event-stream"
+}
+
+// isSSEResponse reports whether res is a Server-Sent Events stream, either
+// because its path is on the allow-list or its Content-Type says so.
+func isSSEResponse(res *http.Response) bool {
+	if isSSERoute(res.Request.URL.Path) {
+		return true
+	}
+	return isSSEContentType(res.Header)
+}
+
+func New(cfg *config.Config) (http.Handler, error) {
+	tlsConfig, err := cfg.TLS.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build TLS config: %w", err)
+	}
+
+	pipeline, err := loadFilterPipeline(cfg.FiltersConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load filter pipeline: %w", err)
+	}
+
+	retryConfig := resilience.RetryConfig{
+		MaxAttempts: cfg.Resilience.RetryMaxAttempts,
+		BaseDelay:   cfg.Resilience.RetryBaseDelay,
+		MaxDelay:    cfg.Resilience.RetryMaxDelay,
+	}
+
+	upstreamTransport, err := cfg.Transport.Build(tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build upstream transport: %w", err)
+	}
+
+	pool, healthPath, healthInterval, err := loadBackendPool(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load backend pool: %w", err)
+	}
+	if err := backend.AuthenticateAll(pool.Backends(), cfg.MaxRetries, cfg.RetryInterval); err != nil {
+		return nil, fmt.Errorf("failed to authenticate backend pool: %w", err)
+	}
+	// The synthesized single-backend pool built from TARGET_SERVER_HOST/PORT
+	// (cfg.BackendPool unset) has no GO_PROXY_BACKEND_POOL_CONFIG health_path
+	// of its own; target-server exposes no /health route, so probing it
+	// would just mark the only backend permanently unhealthy. Health checks
+	// only make sense once an operator has opted into a real pool config.
+	if cfg.BackendPool != "" {
+		backend.StartHealthChecks(pool.Backends(), healthPath, healthInterval, upstreamTransport, make(chan struct{}))
+	}
+
+	proxy := &httputil.ReverseProxy{}
 
 	proxy.Director = func(req *http.Request) {
 		clientIP, _, _ := net.SplitHostPort(req.RemoteAddr)
-		requestLine := fmt.Sprintf("%s %s", req.Method, req.URL.RequestURI())
-		logger.Log(logger.INFO, "Received request", fmt.Sprintf("Client IP: %s, Request: \"%s\"", clientIP, requestLine))
-		if handlers.HasCustomHandler(req.URL.Path) {
+		logger.Log(logger.INFO, "Received request",
+			slog.String("request_id", requestID(req.Context())),
+			slog.String("client_ip", clientIP),
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.RequestURI()))
+
+		*req = *req.WithContext(context.WithValue(req.Context(), startTimeKey{}, time.Now()))
+
+		// Scheme is fixed; Host and the X-Proxy-Token are chosen per-request
+		// by the load balancer in resilientTransport.RoundTrip, once a
+		// backend's been selected.
+		req.URL.Scheme = "https"
+		if isSSERoute(req.URL.Path) {
+			// A gzipped event stream would have to be fully buffered to
+			// decompress, defeating the point of streaming it; a
+			// reconnecting EventSource's Last-Event-ID header is an
+			// ordinary header and reaches the target untouched below.
 			req.Header.Del("Accept-Encoding")
 		}
-
-		req.URL.Scheme = targetURL.Scheme
-		req.URL.Host = targetURL.Host
-		req.Host = targetURL.Host
-		req.Header.Set("X-Proxy-Token", authManager.GetToken())
-		logger.Log(logger.INFO, "Forwarding request to target", "URI: "+req.URL.RequestURI())
 	}
 
 	proxy.ModifyResponse = func(res *http.Response) error {
-		logger.Log(logger.INFO, "Received response from target", fmt.Sprintf("Status: %d %s", res.StatusCode, http.StatusText(res.StatusCode)))
+		var dur time.Duration
+		if start, ok := res.Request.Context().Value(startTimeKey{}).(time.Time); ok {
+			dur = time.Since(start)
+		}
+		logger.Log(logger.INFO, "Received response from backend",
+			slog.String("request_id", requestID(res.Request.Context())),
+			slog.String("method", res.Request.Method),
+			slog.String("path", res.Request.URL.Path),
+			slog.Int("status", res.StatusCode),
+			slog.Int64("upstream_latency_ms", dur.Milliseconds()),
+			slog.Int64("bytes_out", res.ContentLength))
+		globalMetrics.observeRequest(strconv.Itoa(res.StatusCode), res.Request.Method, dur)
 
-		if res.StatusCode == http.StatusOK && handlers.HasCustomHandler(res.Request.URL.Path) {
-			logger.Log(logger.INFO, "Intercepting response for custom handling", "Route: "+res.Request.URL.Path)
-			return handlers.HandleCustomResponse(res)
+		if isSSEResponse(res) {
+			logger.Log(logger.INFO, "Streaming SSE response without buffering", slog.String("path", res.Request.URL.Path))
 		}
 
-		if res.StatusCode == http.StatusForbidden {
-			logger.Log(logger.WARN, "Received 403 Forbidden from target. Triggering re-authentication.")
-			go authManager.RefreshTokenIfNeeded()
+		if err := pipeline.Apply(res); err != nil {
+			logger.Log(logger.ERROR, "Response filter pipeline failed", slog.String("path", res.Request.URL.Path), slog.Any("error", err))
+			return err
+		}
+
+		if res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized {
+			if b, ok := res.Request.Context().Value(backendKey{}).(*backend.Backend); ok {
+				logger.Log(logger.WARN, "Received 401/403 from backend. Triggering re-authentication.", slog.String("backend", b.Key()), slog.Int("status", res.StatusCode))
+				globalMetrics.incReauth()
+				backgroundReauth.Add(1)
+				go func() {
+					defer backgroundReauth.Done()
+					b.Auth.RefreshTokenIfNeeded()
+				}()
+			}
 		}
 		return nil
 	}
 
-	proxy.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	proxy.Transport = &resilientTransport{
+		next:  upstreamTransport,
+		pool:  pool,
+		retry: retryConfig,
 	}
 
 	proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
+		var breakerErr *resilience.BreakerOpenError
+		if errors.As(err, &breakerErr) {
+			logger.Log(logger.WARN, "Backend circuit breaker open; failing fast", slog.Duration("retry_after", breakerErr.RetryAfter))
+			globalMetrics.incUpstreamError()
+			rw.Header().Set("Retry-After", strconv.Itoa(int(breakerErr.RetryAfter.Seconds())))
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if errors.Is(err, backend.ErrNoAvailableBackends) {
+			logger.Log(logger.WARN, "No available backends in pool")
+			globalMetrics.incUpstreamError()
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
 		if err != nil {
+			globalMetrics.incUpstreamError()
 			if netErr, ok := err.(net.Error); ok && !netErr.Timeout() {
-				logger.Log(logger.INFO, "Client connection closed prematurely during proxying", err.Error())
+				logger.Log(logger.INFO, "Client connection closed prematurely during proxying", slog.Any("error", err))
 			} else if err == io.EOF {
-				logger.Log(logger.INFO, "Client connection closed prematurely during proxying", "EOF")
+				logger.Log(logger.INFO, "Client connection closed prematurely during proxying", slog.String("reason", "EOF"))
 			} else {
-				logger.Log(logger.ERROR, "Proxying failed", err)
+				logger.Log(logger.ERROR, "Proxying failed", slog.Any("error", err))
 			}
 		}
 		rw.WriteHeader(http.StatusBadGateway)
 	}
 
-	return proxy
+	metrics := metricsHandler(pool)
+	livez := livezHandler()
+	readyz := readyzHandler(pool)
+	if cfg.AdminAddr != "" {
+		startAdminServer(cfg.AdminAddr, metrics, livez, readyz)
+	}
+
+	mux := http.NewServeMux()
+	if cfg.AdminAddr == "" {
+		mux.Handle("/metrics", metrics)
+		mux.Handle("/livez", livez)
+		mux.Handle("/readyz", readyz)
+	}
+	mux.Handle("/", withRequestID(streamSSE(proxy)))
+	return mux, nil
+}
+
+// startAdminServer launches a plain-HTTP admin listener on addr serving
+// /metrics, /livez, and /readyz, separate from the TLS listener that serves
+// proxied traffic, so operators can scrape and probe it without needing a
+// client certificate or the target's TLS trust chain.
+func startAdminServer(addr string, metrics, livez, readyz http.Handler) {
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/metrics", metrics)
+	adminMux.Handle("/livez", livez)
+	adminMux.Handle("/readyz", readyz)
+
+	go func() {
+		logger.Log(logger.INFO, "Starting admin listener", slog.String("admin_addr", addr))
+		if err := http.ListenAndServe(addr, adminMux); err != nil {
+			logger.Log(logger.ERROR, "Admin listener failed", slog.Any("error", err))
+		}
+	}()
+}
+
+// livezHandler reports liveness: 200 as long as the process is up and
+// serving this handler at all.
+func livezHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// readyzHandler reports readiness: 503 until pool has at least one backend
+// that's both healthy and authenticated, so a load balancer can drain this
+// instance automatically instead of routing it traffic it can't serve.
+func readyzHandler(pool *backend.Pool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !pool.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// loadBackendPool builds the Pool the proxy load-balances across, along
+// with the health-check path/interval it should run at: from
+// cfg.BackendPool if set, or else a single-backend Pool wrapping the
+// classic TARGET_SERVER_HOST/TARGET_SERVER_PORT configuration.
+func loadBackendPool(cfg *config.Config) (*backend.Pool, string, time.Duration, error) {
+	if cfg.BackendPool != "" {
+		return backend.LoadPool(cfg.BackendPool, cfg)
+	}
+	pool, err := backend.BuildDefaultPool(cfg)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return pool, backend.DefaultHealthPath, backend.DefaultHealthInterval, nil
+}
+
+// resilientTransport wraps an http.RoundTripper with load-balanced backend
+// selection, a per-backend circuit breaker, and a full-jitter
+// exponential-backoff retryer: idempotent requests are retried on transient
+// network errors or 5xx responses, and once a backend's breaker trips,
+// calls against it fail fast with a BreakerOpenError instead of hammering a
+// downed target.
+type resilientTransport struct {
+	next  http.RoundTripper
+	pool  *backend.Pool
+	retry resilience.RetryConfig
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	selected, err := t.pool.Select(req)
+	if err != nil {
+		return nil, err
+	}
+	if !selected.Breaker.Allow() {
+		return nil, &resilience.BreakerOpenError{RetryAfter: selected.Breaker.RetryAfter()}
+	}
+
+	req.URL.Host = selected.Key()
+	req.Host = selected.Key()
+	req.Header.Set("X-Proxy-Token", selected.Auth.GetToken())
+	*req = *req.WithContext(context.WithValue(req.Context(), backendKey{}, selected))
+	logger.Log(logger.INFO, "Forwarding request to backend", slog.String("backend", selected.Key()), slog.String("uri", req.URL.RequestURI()))
+
+	selected.IncActive()
+	defer selected.DecActive()
+
+	retryConfig := t.retry
+	if !canRetrySafely(req) {
+		retryConfig.MaxAttempts = 1
+	}
+
+	// The downstream client disconnecting (e.g. an EventSource giving up)
+	// cancels req.Context(); treat that as non-retryable so we don't keep
+	// hammering the target for a client that's no longer listening, and so
+	// the canceled context tears down the in-flight upstream connection
+	// instead of leaking it.
+	shouldRetry := func(err error) bool {
+		return req.Context().Err() == nil
+	}
+
+	var res *http.Response
+	err = resilience.Retry(retryConfig, shouldRetry, func() error {
+		if err := req.Context().Err(); err != nil {
+			return err
+		}
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return bodyErr
+			}
+			req.Body = body
+		}
+
+		var rtErr error
+		res, rtErr = t.next.RoundTrip(req)
+		if rtErr != nil {
+			return rtErr
+		}
+		if res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("upstream returned status %d", res.StatusCode)
+		}
+		return nil
+	})
+
+	if err != nil {
+		selected.Breaker.RecordFailure()
+		return nil, err
+	}
+	selected.Breaker.RecordSuccess()
+	return res, nil
+}
+
+// canRetrySafely reports whether req may be retransmitted without risking
+// a non-idempotent side effect: GET/HEAD/OPTIONS are always safe, PUT and
+// DELETE are safe only if their body can be rewound via GetBody (or there
+// was no body to begin with), and any other method (e.g. POST, PATCH) is
+// never retried.
+func canRetrySafely(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPut, http.MethodDelete:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	default:
+		return false
+	}
+}
+
+// metricsHandler serves a Prometheus text-exposition snapshot: the
+// process-wide request/latency/reauth/upstream-error counters in
+// globalMetrics, followed by a per-backend breakdown of health, in-flight
+// request count, upstream circuit breaker, and auth circuit breaker.
+func metricsHandler(pool *backend.Pool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		globalMetrics.writeMetrics(w)
+
+		for _, b := range pool.Backends() {
+			m := b.Breaker.Metrics()
+			authMetrics := b.Auth.BreakerMetrics()
+			fmt.Fprintf(w, "backend_healthy{backend=%q} %d\n", b.Key(), boolToFloat(b.Healthy()))
+			fmt.Fprintf(w, "backend_active_requests{backend=%q} %d\n", b.Key(), b.Active())
+			fmt.Fprintf(w, "backend_breaker_state{backend=%q,state=%q} 1\n", b.Key(), m.State)
+			fmt.Fprintf(w, "backend_breaker_requests{backend=%q} %d\n", b.Key(), m.Requests)
+			fmt.Fprintf(w, "backend_breaker_failures{backend=%q} %d\n", b.Key(), m.Failures)
+			fmt.Fprintf(w, "backend_auth_breaker_state{backend=%q,state=%q} 1\n", b.Key(), authMetrics.State)
+			fmt.Fprintf(w, "backend_auth_breaker_requests{backend=%q} %d\n", b.Key(), authMetrics.Requests)
+			fmt.Fprintf(w, "backend_auth_breaker_failures{backend=%q} %d\n", b.Key(), authMetrics.Failures)
+		}
+	})
+}
+
+// boolToFloat renders b as the 0/1 a Prometheus gauge sample requires;
+// exposition format has no boolean type.
+func boolToFloat(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// loadFilterPipeline loads the response-transform pipeline from path, or
+// falls back to handlers.DefaultPipeline (the built-in "/wheredidicomefrom"
+// demo) when path is empty.
+func loadFilterPipeline(path string) (*handlers.Pipeline, error) {
+	if path == "" {
+		return handlers.DefaultPipeline(), nil
+	}
+	return handlers.LoadPipeline(path)
 }
@@ -0,0 +1,184 @@
+// Package resilience provides a circuit breaker and an exponential-backoff
+// retryer shared by the auth manager and the proxy's upstream transport.
+package resilience
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is one of the three circuit-breaker states.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig configures a Breaker's trip and reset thresholds.
+type BreakerConfig struct {
+	FailureRatio float64       // trip once failures/requests >= this, after MinRequests is reached
+	MinRequests  int           // minimum requests observed before FailureRatio is evaluated
+	OpenDuration time.Duration // how long the breaker stays Open before allowing a half-open probe
+}
+
+// Breaker is a closed/open/half-open circuit breaker. Construct with
+// NewBreaker; the zero value is not usable.
+type Breaker struct {
+	cfg BreakerConfig
+
+	mu       sync.Mutex
+	state    State
+	openedAt time.Time
+	requests int
+	failures int
+}
+
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	return &Breaker{cfg: cfg}
+}
+
+// Allow reports whether a new call may proceed. It returns false while Open
+// and OpenDuration hasn't elapsed yet. Once it has, exactly one caller is
+// let through as a half-open probe; further calls are refused until that
+// probe reports its outcome via RecordSuccess/RecordFailure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return true
+	case HalfOpen:
+		return false
+	default: // Open
+		if time.Since(b.openedAt) < b.cfg.OpenDuration {
+			return false
+		}
+		b.state = HalfOpen
+		return true
+	}
+}
+
+// RecordSuccess reports a successful call. From HalfOpen it closes the
+// breaker and resets its counters; from Closed it just accumulates.
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.reset()
+		return
+	}
+	b.requests++
+}
+
+// RecordFailure reports a failed call. A failed half-open probe re-opens
+// the breaker immediately; otherwise it trips once the failure ratio
+// crosses FailureRatio over at least MinRequests requests.
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.trip()
+		return
+	}
+
+	b.requests++
+	b.failures++
+	if b.requests >= b.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= b.cfg.FailureRatio {
+		b.trip()
+	}
+}
+
+func (b *Breaker) trip() {
+	b.state = Open
+	b.openedAt = time.Now()
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *Breaker) reset() {
+	b.state = Closed
+	b.requests = 0
+	b.failures = 0
+}
+
+// State returns the breaker's current state and accumulated counts.
+func (b *Breaker) State() (state State, requests, failures int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state, b.requests, b.failures
+}
+
+// CanAttempt reports whether a call would currently be let through,
+// without committing to one: unlike Allow, it never transitions Open to
+// HalfOpen. Use it to filter candidates (e.g. load-balancer backend
+// selection) when the caller isn't the one that will actually place the
+// call and record its outcome.
+func (b *Breaker) CanAttempt() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return true
+	case HalfOpen:
+		return false
+	default: // Open
+		return time.Since(b.openedAt) >= b.cfg.OpenDuration
+	}
+}
+
+// RetryAfter returns how long callers should wait before the breaker will
+// allow another attempt. It's 0 whenever the breaker isn't Open.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != Open {
+		return 0
+	}
+	remaining := b.cfg.OpenDuration - time.Since(b.openedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Metrics is a snapshot of a Breaker's state and counts, suitable for
+// logging or serving from a /metrics-style endpoint.
+type Metrics struct {
+	State    string
+	Requests int
+	Failures int
+}
+
+func (b *Breaker) Metrics() Metrics {
+	state, requests, failures := b.State()
+	return Metrics{State: state.String(), Requests: requests, Failures: failures}
+}
+
+// BreakerOpenError is returned by callers built around a Breaker (such as a
+// RoundTripper) when a call is rejected because the breaker is Open.
+type BreakerOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *BreakerOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open, retry after %s", e.RetryAfter)
+}
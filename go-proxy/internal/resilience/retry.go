@@ -0,0 +1,50 @@
+package resilience
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryConfig configures an exponential-backoff-with-full-jitter retryer.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// BackoffDelay returns the full-jitter backoff delay for the given
+// 0-indexed attempt: a random uniform value in
+// [0, min(MaxDelay, BaseDelay*2^attempt)).
+func (c RetryConfig) BackoffDelay(attempt int) time.Duration {
+	capped := float64(c.BaseDelay) * math.Pow(2, float64(attempt))
+	if max := float64(c.MaxDelay); c.MaxDelay > 0 && capped > max {
+		capped = max
+	}
+	if capped <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(capped)))
+}
+
+// Retry calls fn up to MaxAttempts times, sleeping a full-jitter backoff
+// delay between attempts. It stops early once fn succeeds or shouldRetry
+// returns false for the error fn returned.
+func Retry(cfg RetryConfig, shouldRetry func(error) bool, fn func() error) error {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = fn()
+		if err == nil || !shouldRetry(err) {
+			return err
+		}
+		if attempt < maxAttempts-1 {
+			time.Sleep(cfg.BackoffDelay(attempt))
+		}
+	}
+	return err
+}
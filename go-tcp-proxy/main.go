@@ -1,18 +1,30 @@
 package main
 
 import (
+	"bufio"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// https://pkg.go.dev/bufio
+// https://pkg.go.dev/encoding/base64
 // https://pkg.go.dev/fmt
 // https://pkg.go.dev/io
 // https://pkg.go.dev/log
 // https://pkg.go.dev/net
+// https://pkg.go.dev/net/http
 // https://pkg.go.dev/os
+// https://pkg.go.dev/strconv
+// https://pkg.go.dev/strings
+// https://pkg.go.dev/time
 
 func main() {
 	listenPort := os.Getenv("PROXY_SERVER_GO_PORT")
@@ -40,15 +52,35 @@ func main() {
 
 	defer listener.Close()
 
-	log.Printf("TCP proxy listening on %s, forwarding to %s", listenAddress, targetAddress)
+	mode := os.Getenv("PROXY_MODE")
+	if mode == "" {
+		mode = "tcp"
+	}
 
-	for {
-		clientConnection, err := listener.Accept()
-		if err != nil {
-			log.Printf("Failed to accept new connection: %v", err)
-			continue
+	switch mode {
+	case "http":
+		proxyAuth := newProxyAuth()
+		log.Printf("HTTP forward proxy listening on %s", listenAddress)
+		for {
+			clientConnection, err := listener.Accept()
+			if err != nil {
+				log.Printf("Failed to accept new connection: %v", err)
+				continue
+			}
+			go handleHTTPConnection(clientConnection, proxyAuth)
+		}
+	case "tcp":
+		log.Printf("TCP proxy listening on %s, forwarding to %s", listenAddress, targetAddress)
+		for {
+			clientConnection, err := listener.Accept()
+			if err != nil {
+				log.Printf("Failed to accept new connection: %v", err)
+				continue
+			}
+			go handleConnection(clientConnection, targetAddress)
 		}
-		go handleConnection(clientConnection, targetAddress)
+	default:
+		log.Fatalf("Unknown PROXY_MODE %q, expected \"tcp\" or \"http\"", mode)
 	}
 }
 
@@ -60,7 +92,7 @@ func handleConnection(clientConnection net.Conn, targetAddress string) {
 		log.Printf("Failed to connect to target %s: %v", targetAddress, err)
 		return
 	}
-	
+
 	defer targetConnection.Close()
 
 	log.Printf("New connection from %s, proxying to %s", clientConnection.RemoteAddr(), targetAddress)
@@ -68,7 +100,7 @@ func handleConnection(clientConnection net.Conn, targetAddress string) {
 	// Bidirectional transfer using goroutines to copy data in both directions.
 	go copyData(clientConnection, targetConnection)
 	copyData(targetConnection, clientConnection)
-	
+
 	log.Printf("Connection closed for %s", clientConnection.RemoteAddr())
 }
 
@@ -76,4 +108,214 @@ func copyData(dst io.Writer, src io.Reader) {
 	if _, err := io.Copy(dst, src); err != nil {
 		log.Printf("Error copying data: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// idleTimeout bounds how long a connection may sit without the client
+// sending its next request, so half-closed peers get reaped instead of
+// piling up goroutines forever.
+func idleTimeout() time.Duration {
+	ms, err := strconv.Atoi(os.Getenv("PROXY_IDLE_TIMEOUT_MS"))
+	if err != nil || ms <= 0 {
+		ms = 30000
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// deadlineConn wraps a net.Conn so every Read/Write refreshes its deadline
+// by timeout. A one-shot SetReadDeadline only guards the first read off a
+// connection; wrapping it like this makes the idle timeout cover the
+// CONNECT/plain-HTTP relay phase too, where copyData's io.Copy would
+// otherwise block forever on a peer that goes half-closed mid-stream.
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c deadlineConn) Read(p []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Read(p)
+}
+
+func (c deadlineConn) Write(p []byte) (int, error) {
+	c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Write(p)
+}
+
+// proxyAuth optionally gates PROXY_MODE=http connections behind a
+// Proxy-Authorization check, basic or bearer, and/or a hidden "trigger"
+// domain that forces a browser's native credential prompt.
+type proxyAuth struct {
+	basicUser     string
+	basicPass     string
+	bearerToken   string
+	triggerDomain string
+}
+
+func newProxyAuth() *proxyAuth {
+	return &proxyAuth{
+		basicUser:     os.Getenv("PROXY_AUTH_USER"),
+		basicPass:     os.Getenv("PROXY_AUTH_PASS"),
+		bearerToken:   os.Getenv("PROXY_AUTH_TOKEN"),
+		triggerDomain: os.Getenv("PROXY_AUTH_TRIGGER_DOMAIN"),
+	}
+}
+
+func (a *proxyAuth) required() bool {
+	return a.basicUser != "" || a.bearerToken != ""
+}
+
+// verify checks the Proxy-Authorization header of req against whichever
+// scheme is configured. With no backend configured, every request passes.
+func (a *proxyAuth) verify(req *http.Request) bool {
+	if !a.required() {
+		return true
+	}
+
+	header := req.Header.Get("Proxy-Authorization")
+	scheme, value, ok := strings.Cut(header, " ")
+	if !ok {
+		return false
+	}
+
+	switch scheme {
+	case "Basic":
+		if a.basicUser == "" {
+			return false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return false
+		}
+		user, pass, ok := strings.Cut(string(decoded), ":")
+		return ok && user == a.basicUser && pass == a.basicPass
+	case "Bearer":
+		return a.bearerToken != "" && value == a.bearerToken
+	default:
+		return false
+	}
+}
+
+// requiresAuthChallenge returns true when the request is visiting the
+// configured hidden trigger domain, mirroring dumb-proxy's browser-auth
+// flow: visiting that one magic host forces a 407 so the browser pops its
+// native credential dialog, and the browser then replays those same
+// credentials on every subsequent request through the proxy.
+func (a *proxyAuth) requiresAuthChallenge(req *http.Request) bool {
+	if a.triggerDomain == "" {
+		return false
+	}
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host == a.triggerDomain
+}
+
+func sendProxyAuthRequired(w io.Writer) {
+	fmt.Fprint(w, "HTTP/1.1 407 Proxy Authentication Required\r\n"+
+		"Proxy-Authenticate: Basic realm=\"go-tcp-proxy\"\r\n"+
+		"Content-Length: 0\r\n"+
+		"Connection: close\r\n\r\n")
+}
+
+// handleHTTPConnection implements PROXY_MODE=http: the first request read
+// off the client connection is either an HTTP CONNECT, which gets tunneled
+// after a 200 reply, or a plain HTTP request, which is rewritten to
+// origin-form and forwarded to its target before the rest of the
+// connection is spliced through like the plain TCP mode.
+func handleHTTPConnection(clientConnection net.Conn, auth *proxyAuth) {
+	defer clientConnection.Close()
+
+	timeout := idleTimeout()
+	// Wrapping clientConnection here, before the bufio.Reader is built on
+	// top of it, means every read off the client for the rest of this
+	// connection's life (the initial request line and the relay phase
+	// alike) refreshes the deadline, instead of it lapsing once the
+	// request is parsed.
+	dc := deadlineConn{Conn: clientConnection, timeout: timeout}
+
+	reader := bufio.NewReader(dc)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		if err != io.EOF {
+			log.Printf("Failed to read request from %s: %v", clientConnection.RemoteAddr(), err)
+		}
+		return
+	}
+
+	if auth.requiresAuthChallenge(req) {
+		sendProxyAuthRequired(dc)
+		return
+	}
+
+	if !auth.verify(req) {
+		log.Printf("Rejecting unauthenticated proxy request from %s", clientConnection.RemoteAddr())
+		sendProxyAuthRequired(dc)
+		return
+	}
+
+	if req.Method == http.MethodConnect {
+		handleConnectTunnel(dc, req, timeout)
+		return
+	}
+
+	handlePlainHTTPRequest(dc, reader, req, timeout)
+}
+
+func handleConnectTunnel(clientConnection deadlineConn, req *http.Request, timeout time.Duration) {
+	targetConnection, err := net.DialTimeout("tcp", req.Host, timeout)
+	if err != nil {
+		log.Printf("Failed to CONNECT to %s: %v", req.Host, err)
+		fmt.Fprint(clientConnection, "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n")
+		return
+	}
+	defer targetConnection.Close()
+	target := deadlineConn{Conn: targetConnection, timeout: timeout}
+
+	if _, err := fmt.Fprint(clientConnection, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		log.Printf("Failed to acknowledge CONNECT to %s: %v", req.Host, err)
+		return
+	}
+
+	log.Printf("Tunneling CONNECT %s for %s", req.Host, clientConnection.RemoteAddr())
+	go copyData(target, clientConnection)
+	copyData(clientConnection, target)
+	log.Printf("CONNECT tunnel to %s closed for %s", req.Host, clientConnection.RemoteAddr())
+}
+
+func handlePlainHTTPRequest(clientConnection deadlineConn, reader *bufio.Reader, req *http.Request, timeout time.Duration) {
+	targetAddress := req.Host
+	if _, _, err := net.SplitHostPort(targetAddress); err != nil {
+		targetAddress = net.JoinHostPort(targetAddress, "80")
+	}
+
+	targetConnection, err := net.DialTimeout("tcp", targetAddress, timeout)
+	if err != nil {
+		log.Printf("Failed to connect to %s: %v", targetAddress, err)
+		fmt.Fprint(clientConnection, "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n")
+		return
+	}
+	defer targetConnection.Close()
+	target := deadlineConn{Conn: targetConnection, timeout: timeout}
+
+	// Rewrite the absolute-form request line ("GET http://host/path HTTP/1.1")
+	// into origin-form ("GET /path HTTP/1.1") before forwarding upstream.
+	req.RequestURI = ""
+	req.URL.Scheme = ""
+	req.URL.Host = ""
+	req.Header.Del("Proxy-Authorization")
+	req.Header.Del("Proxy-Connection")
+	if err := req.Write(target); err != nil {
+		log.Printf("Failed to forward request to %s: %v", targetAddress, err)
+		return
+	}
+
+	log.Printf("Forwarded %s %s to %s for %s", req.Method, req.URL.RequestURI(), targetAddress, clientConnection.RemoteAddr())
+
+	// req.Write has already sent this request's body; any further bytes on
+	// this connection (the rest of a keep-alive session, including anything
+	// reader has already buffered) are spliced through as plain bytes.
+	go copyData(target, reader)
+	copyData(clientConnection, target)
+	log.Printf("Connection to %s closed for %s", targetAddress, clientConnection.RemoteAddr())
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"flag"
-	"fmt"
+	"log/slog"
 
 	// Local imports
 	"target-server/internal/database"
@@ -10,7 +10,7 @@ import (
 )
 
 func main() {
-	logger.Init()
+	logger.Init(logger.ConfigFromEnv())
 
 	id := flag.String("id", "", "The Admin 'id' for the proxy.")
 	secret := flag.String("secret", "", "The Admin 'secret' for the proxy.")
@@ -28,8 +28,8 @@ func main() {
 	defer db.Close()
 
 	if ok, _ := db.AddUser(*id, *secret); ok {
-		logger.Log(logger.SUCCESS, "Added admin user", fmt.Sprintf("Admin ID: '%s'", *id))
+		logger.Log(logger.SUCCESS, "Added admin user", slog.String("admin_id", *id))
 	} else {
-		logger.Log(logger.ERROR, "Failed to add user", fmt.Sprintf("Admin ID: '%s'", *id))
+		logger.Log(logger.ERROR, "Failed to add user", slog.String("admin_id", *id))
 	}
 }
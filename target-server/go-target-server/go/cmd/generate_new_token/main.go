@@ -3,6 +3,7 @@ package main
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"log/slog"
 	"os"
 
 	// Local imports
@@ -10,17 +11,17 @@ import (
 )
 
 func main() {
-	logger.Init()
+	logger.Init(logger.ConfigFromEnv())
 
 	tokenBytes := make([]byte, 24)
 	if _, err := rand.Read(tokenBytes); err != nil {
-		logger.Log(logger.ERROR, "Failed to generate random bytes for token", err.Error())
+		logger.Log(logger.ERROR, "Failed to generate random bytes for token", slog.Any("error", err))
 		return
 	}
 	token := base64.URLEncoding.EncodeToString(tokenBytes)
 
 	if err := os.WriteFile("master_token.txt", []byte(token), 0644); err != nil {
-		logger.Log(logger.ERROR, "Failed to write token to master_token.txt", err.Error())
+		logger.Log(logger.ERROR, "Failed to write token to master_token.txt", slog.Any("error", err))
 		return
 	}
 
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -11,13 +15,14 @@ import (
 	"time"
 
 	// Local imports
-	"target-server/internal/database"
+	"target-server/internal/auth"
 	"target-server/internal/logger"
 )
 
 var (
 	masterToken string
 	tokenLock   sync.RWMutex
+	authBackend auth.Backend
 )
 
 func rereadTokenPeriodically() {
@@ -27,7 +32,7 @@ func rereadTokenPeriodically() {
 	for range ticker.C {
 		newToken, err := os.ReadFile("master_token.txt")
 		if err != nil {
-			logger.Log(logger.ERROR, "master_token.txt not found during periodic reread.", err.Error())
+			logger.Log(logger.ERROR, "master_token.txt not found during periodic reread.", slog.Any("error", err))
 			continue
 		}
 
@@ -52,13 +57,18 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 		next.ServeHTTP(lrw, r)
 
-		extras := fmt.Sprintf("Code: %d, Client IP: %s, Method: %s, Path: %s, Duration: %s",
-			lrw.statusCode, r.RemoteAddr, r.Method, r.URL.Path, time.Since(start))
+		fields := []any{
+			slog.Int("status", lrw.statusCode),
+			slog.String("client_ip", r.RemoteAddr),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Duration("dur", time.Since(start)),
+		}
 
 		if lrw.statusCode >= 400 {
-			logger.Log(logger.ERROR, "Request failed", extras)
+			logger.Log(logger.ERROR, "Request failed", fields...)
 		} else {
-			logger.Log(logger.INFO, "Request handled successfully", extras)
+			logger.Log(logger.INFO, "Request handled successfully", fields...)
 		}
 	})
 }
@@ -73,7 +83,7 @@ func authMiddleware(next http.Handler) http.Handler {
 
 		if !isValid {
 			msg := "Forbidden: Invalid or missing proxy token."
-			logger.Log(logger.WARN, msg, fmt.Sprintf("IP: %s", r.RemoteAddr))
+			logger.Log(logger.WARN, msg, slog.String("client_ip", r.RemoteAddr))
 			http.Error(w, msg, http.StatusForbidden)
 			return
 		}
@@ -87,6 +97,15 @@ func authApiHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// mTLS-authenticated peers skip straight to token issuance.
+	if cv, ok := authBackend.(auth.CertVerifier); ok && r.TLS != nil {
+		if verified, err := cv.VerifyCert(r.TLS); err == nil && verified {
+			logger.Log(logger.SUCCESS, "Authentication successful via client certificate", slog.String("subject", r.TLS.PeerCertificates[0].Subject.String()))
+			writeMasterToken(w)
+			return
+		}
+	}
+
 	var creds struct {
 		ClientID     string `json:"client_id"`
 		ClientSecret string `json:"client_secret"`
@@ -97,21 +116,18 @@ func authApiHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := database.New("users.db")
-	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	isValid, err := db.VerifyUser(creds.ClientID, creds.ClientSecret)
+	isValid, err := authBackend.VerifyUser(creds.ClientID, creds.ClientSecret)
 	if err != nil || !isValid {
-		logger.Log(logger.WARN, "Unauthorized authentication attempt", fmt.Sprintf("client_id: %s", creds.ClientID))
+		logger.Log(logger.WARN, "Unauthorized authentication attempt", slog.String("client_id", creds.ClientID))
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	logger.Log(logger.SUCCESS, "Authentication successful", fmt.Sprintf("client_id: %s", creds.ClientID))
+	logger.Log(logger.SUCCESS, "Authentication successful", slog.String("client_id", creds.ClientID))
+	writeMasterToken(w)
+}
+
+func writeMasterToken(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 
 	tokenLock.RLock()
@@ -122,7 +138,7 @@ func authApiHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func streamHandler(w http.ResponseWriter, r *http.Request) {
-	logger.Log(logger.INFO, "GET /stream request received", fmt.Sprintf("Client IP: %s", r.RemoteAddr))
+	logger.Log(logger.INFO, "GET /stream request received", slog.String("client_ip", r.RemoteAddr))
 
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -135,7 +151,7 @@ func streamHandler(w http.ResponseWriter, r *http.Request) {
 
 		_, err := w.Write([]byte(message))
 		if err != nil {
-			logger.Log(logger.INFO, "Client disconnected from stream.", fmt.Sprintf("Client IP: %s", r.RemoteAddr))
+			logger.Log(logger.INFO, "Client disconnected from stream.", slog.String("client_ip", r.RemoteAddr))
 			break
 		}
 
@@ -175,12 +191,50 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+// buildTLSConfig optionally requires and verifies a client certificate on
+// every connection, so the proxy can authenticate via mTLS in addition to
+// (or eventually instead of) its bearer token. With no CA bundle configured
+// the server accepts any client, same as before.
+func buildTLSConfig() (*tls.Config, error) {
+	caBundlePath := os.Getenv("TARGET_SERVER_CLIENT_CA")
+	if caBundlePath == "" {
+		return &tls.Config{}, nil
+	}
+
+	caBytes, err := os.ReadFile(caBundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TARGET_SERVER_CLIENT_CA %q: %w", caBundlePath, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in TARGET_SERVER_CLIENT_CA %q", caBundlePath)
+	}
+
+	return &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}, nil
+}
+
 func main() {
-	logger.Init()
+	listCiphers := flag.Bool("list-ciphers", false, "Print the compiled TLS cipher suites and exit")
+	flag.Parse()
+
+	if *listCiphers {
+		for _, suite := range tls.CipherSuites() {
+			fmt.Println(suite.Name)
+		}
+		for _, suite := range tls.InsecureCipherSuites() {
+			fmt.Println(suite.Name + " (insecure)")
+		}
+		return
+	}
+
+	logger.Init(logger.ConfigFromEnv())
 
 	tokenBytes, err := os.ReadFile("master_token.txt")
 	if err != nil {
-		logger.Log(logger.ERROR, "Could not read initial master token at startup. The server will not start.", err.Error())
+		logger.Log(logger.ERROR, "Could not read initial master token at startup. The server will not start.", slog.Any("error", err))
 		return
 	}
 	masterToken = strings.TrimSpace(string(tokenBytes))
@@ -193,6 +247,17 @@ func main() {
 	go rereadTokenPeriodically()
 	logger.Log(logger.INFO, "Started background thread for polling master_token.txt.")
 
+	authBackendStr := os.Getenv("AUTH_BACKEND")
+	if authBackendStr == "" {
+		authBackendStr = "sqlite://users.db"
+	}
+	authBackend, err = auth.NewAuth(authBackendStr)
+	if err != nil {
+		logger.Log(logger.ERROR, "Failed to initialize auth backend", slog.Any("error", err))
+		return
+	}
+	logger.Log(logger.INFO, "Auth backend initialized", slog.String("auth_backend", authBackendStr))
+
 	host := os.Getenv("TARGET_SERVER_HOST")
 	port := os.Getenv("TARGET_SERVER_PORT")
 	if port == "" || host == "" {
@@ -209,15 +274,30 @@ func main() {
 	http.Handle("/stream", authMiddleware(http.HandlerFunc(streamHandler)))
 	http.Handle("/", authMiddleware(fileHandler))
 
-	logger.Log(logger.INFO, fmt.Sprintf("Starting secure HTTPS server on https://%s", addr))
+	logger.Log(logger.INFO, "Starting secure HTTPS server", slog.String("addr", addr))
 	logger.Log(logger.INFO, "Authentication endpoint active at /api/v1/auth")
 	logger.Log(logger.INFO, "Image endpoint active at /api/v1/image")
 	logger.Log(logger.INFO, "Streaming test endpoint active at /stream")
 
 	loggedRouter := loggingMiddleware(http.DefaultServeMux)
 
-	err = http.ListenAndServeTLS(addr, "cert.pem", "key.pem", loggedRouter)
+	tlsConfig, err := buildTLSConfig()
+	if err != nil {
+		logger.Log(logger.ERROR, "Failed to build TLS config", slog.Any("error", err))
+		return
+	}
+	if tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+		logger.Log(logger.INFO, "Requiring and verifying client certificates (mTLS)")
+	}
+
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   loggedRouter,
+		TLSConfig: tlsConfig,
+	}
+
+	err = server.ListenAndServeTLS("cert.pem", "key.pem")
 	if err != nil {
-		logger.Log(logger.ERROR, "Server failed to start", err.Error())
+		logger.Log(logger.ERROR, "Server failed to start", slog.Any("error", err))
 	}
 }
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	// Local imports
+	"target-server/internal/database"
+	"target-server/internal/logger"
+)
+
+// Backend verifies a client_id/client_secret pair presented to /api/v1/auth.
+type Backend interface {
+	VerifyUser(clientID, clientSecret string) (bool, error)
+}
+
+// CertVerifier is implemented by backends that can also authenticate a peer
+// directly off the TLS handshake, letting /api/v1/auth short-circuit token
+// issuance for mTLS-authenticated peers without a client_id/client_secret body.
+type CertVerifier interface {
+	VerifyCert(state *tls.ConnectionState) (bool, error)
+}
+
+// NewAuth selects and constructs a Backend from a parameterized URL, e.g.
+//
+//	sqlite://users.db
+//	htpasswd:///etc/proxy.htpasswd
+//	static://?user=admin&pass=hunter2
+//	none://
+//	cert://?ca=ca.pem
+//
+// The scheme picks the backend; everything else (host/path/query) is the
+// backend's own parameter string.
+func NewAuth(paramstr string) (Backend, error) {
+	u, err := url.Parse(paramstr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth backend string %q: %w", paramstr, err)
+	}
+
+	switch u.Scheme {
+	case "sqlite":
+		dbFile := u.Host + u.Path
+		if dbFile == "" {
+			return nil, fmt.Errorf("sqlite auth backend requires a db path, e.g. sqlite://users.db")
+		}
+		return newSQLiteBackend(dbFile)
+	case "htpasswd":
+		path := u.Host + u.Path
+		if path == "" {
+			return nil, fmt.Errorf("htpasswd auth backend requires a file path, e.g. htpasswd:///etc/proxy.htpasswd")
+		}
+		return newHtpasswdBackend(path)
+	case "static":
+		return newStaticBackend(u.Query().Get("user"), u.Query().Get("pass")), nil
+	case "none":
+		return noneBackend{}, nil
+	case "cert":
+		return newCertBackend(u.Query().Get("ca"))
+	default:
+		return nil, fmt.Errorf("unknown auth backend scheme %q", u.Scheme)
+	}
+}
+
+// sqliteBackend wraps the existing bcrypt-in-sqlite user store.
+type sqliteBackend struct {
+	db *database.Database
+}
+
+func newSQLiteBackend(dbFile string) (*sqliteBackend, error) {
+	db, err := database.New(dbFile)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteBackend{db: db}, nil
+}
+
+func (b *sqliteBackend) VerifyUser(clientID, clientSecret string) (bool, error) {
+	return b.db.VerifyUser(clientID, clientSecret)
+}
+
+// staticBackend checks credentials against a single fixed user/pass pair,
+// useful for local testing or single-operator deployments.
+type staticBackend struct {
+	user string
+	pass string
+}
+
+func newStaticBackend(user, pass string) *staticBackend {
+	return &staticBackend{user: user, pass: pass}
+}
+
+func (b *staticBackend) VerifyUser(clientID, clientSecret string) (bool, error) {
+	if b.user == "" {
+		return false, nil
+	}
+	return clientID == b.user && clientSecret == b.pass, nil
+}
+
+// noneBackend accepts any credentials. It exists for local development
+// against a proxy that isn't enforcing auth at all.
+type noneBackend struct{}
+
+func (noneBackend) VerifyUser(clientID, clientSecret string) (bool, error) {
+	return true, nil
+}
+
+// htpasswdBackend verifies against an Apache-style htpasswd file
+// ("user:hash" per line) and hot-reloads the file when its mtime changes,
+// mirroring the target server's own periodic master-token reread.
+type htpasswdBackend struct {
+	path string
+
+	mu      sync.RWMutex
+	modTime time.Time
+	entries map[string]string
+}
+
+func newHtpasswdBackend(path string) (*htpasswdBackend, error) {
+	b := &htpasswdBackend{path: path, entries: map[string]string{}}
+	if err := b.reload(); err != nil {
+		return nil, err
+	}
+	go b.watch()
+	return b, nil
+}
+
+func (b *htpasswdBackend) watch() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		info, err := os.Stat(b.path)
+		if err != nil {
+			logger.Log(logger.ERROR, "htpasswd file not found during periodic reread.", slog.Any("error", err))
+			continue
+		}
+
+		b.mu.RLock()
+		unchanged := info.ModTime().Equal(b.modTime)
+		b.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		if err := b.reload(); err != nil {
+			logger.Log(logger.ERROR, "Failed to reload htpasswd file", slog.Any("error", err))
+			continue
+		}
+		logger.Log(logger.INFO, "htpasswd file has been reloaded from disk.", slog.String("path", b.path))
+	}
+}
+
+func (b *htpasswdBackend) reload() error {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(b.path)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]string)
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		entries[user] = hash
+	}
+
+	b.mu.Lock()
+	b.entries = entries
+	b.modTime = info.ModTime()
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *htpasswdBackend) VerifyUser(clientID, clientSecret string) (bool, error) {
+	b.mu.RLock()
+	hash, ok := b.entries[clientID]
+	b.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(clientSecret))
+		return err == nil, nil
+	default:
+		return false, fmt.Errorf("htpasswd backend: unsupported hash format for user %q", clientID)
+	}
+}
+
+// certBackend authenticates a peer by validating its client certificate
+// against a CA bundle on the TLS connection state, with no client_id/secret
+// involved.
+type certBackend struct {
+	caPool *x509.CertPool
+}
+
+func newCertBackend(caPath string) (*certBackend, error) {
+	if caPath == "" {
+		return nil, fmt.Errorf("cert auth backend requires a ?ca= bundle path, e.g. cert://?ca=ca.pem")
+	}
+
+	caBytes, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle %q: %w", caPath, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %q", caPath)
+	}
+
+	return &certBackend{caPool: pool}, nil
+}
+
+// VerifyUser always fails: a cert backend only authenticates via the TLS
+// handshake, never via posted credentials.
+func (b *certBackend) VerifyUser(clientID, clientSecret string) (bool, error) {
+	return false, nil
+}
+
+func (b *certBackend) VerifyCert(state *tls.ConnectionState) (bool, error) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return false, nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	opts := x509.VerifyOptions{
+		Roots:         b.caPool,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(opts); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
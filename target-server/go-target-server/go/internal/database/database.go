@@ -2,7 +2,7 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
+	"log/slog"
 
 	_ "github.com/mattn/go-sqlite3"
 	"golang.org/x/crypto/bcrypt"
@@ -18,7 +18,7 @@ type Database struct {
 func New(dbFile string) (*Database, error) {
 	conn, err := sql.Open("sqlite3", dbFile)
 	if err != nil {
-		logger.Log(logger.ERROR, "Database connection failed", fmt.Sprintf("DB: '%s', Error: %v", dbFile, err))
+		logger.Log(logger.ERROR, "Database connection failed", slog.String("db", dbFile), slog.Any("error", err))
 		return nil, err
 	}
 
@@ -28,7 +28,7 @@ func New(dbFile string) (*Database, error) {
         admin_secret_hash TEXT NOT NULL
     );`
 	if _, err := conn.Exec(createTableSQL); err != nil {
-		logger.Log(logger.ERROR, "Failed to create proxy_users table", err.Error())
+		logger.Log(logger.ERROR, "Failed to create proxy_users table", slog.Any("error", err))
 		return nil, err
 	}
 
@@ -38,14 +38,14 @@ func New(dbFile string) (*Database, error) {
 func (db *Database) AddUser(adminID, adminSecret string) (bool, error) {
 	hashedSecret, err := bcrypt.GenerateFromPassword([]byte(adminSecret), bcrypt.DefaultCost)
 	if err != nil {
-		logger.Log(logger.ERROR, "Failed to hash admin secret", err.Error())
+		logger.Log(logger.ERROR, "Failed to hash admin secret", slog.Any("error", err))
 		return false, err
 	}
 
 	insertSQL := `INSERT OR REPLACE INTO proxy_users (admin_id, admin_secret_hash) VALUES (?, ?)`
 	_, err = db.Exec(insertSQL, adminID, string(hashedSecret))
 	if err != nil {
-		logger.Log(logger.ERROR, "Failed to add admin user", fmt.Sprintf("Admin ID: '%s', Error: %v", adminID, err))
+		logger.Log(logger.ERROR, "Failed to add admin user", slog.String("admin_id", adminID), slog.Any("error", err))
 		return false, err
 	}
 	return true, nil
@@ -63,7 +63,7 @@ func (db *Database) VerifyUser(adminID, adminSecret string) (bool, error) {
 		if err == sql.ErrNoRows {
 			return false, nil
 		}
-		logger.Log(logger.ERROR, "Failed to query admin user", fmt.Sprintf("Admin ID: '%s', Error: %v", adminID, err))
+		logger.Log(logger.ERROR, "Failed to query admin user", slog.String("admin_id", adminID), slog.Any("error", err))
 		return false, err
 	}
 